@@ -1,31 +1,117 @@
+// Package api is a client for the twitter154 API on RapidAPI.
+//
+// Methods that return a slice return a non-nil slice, empty if there are no
+// results, when they succeed, and a nil slice when they fail. The exceptions
+// are WithPartialResults, under which a failed paginated call also returns
+// the results fetched before the failure, and batch methods such as
+// GetUsersByUsername, which report failures per item.
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/ratelimit"
 )
 
 const (
 	_pageLimit = 100
+
+	// _pingUserId is the user ID of @Twitter, used as a known-good probe.
+	_pingUserId = "783214"
 )
 
 var (
-	ErrNotImplemented = errors.New("not implemented")
+	ErrNotImplemented  = errors.New("not implemented")
+	ErrRepeatedToken   = errors.New("continuation token repeated")
+	ErrTooManyPages    = errors.New("too many pages")
+	ErrUnrecognizedURL = errors.New("unrecognized url")
+	ErrReadOnly        = errors.New("method not allowed on a read-only client")
+	ErrNoQuota         = errors.New("quota not reported")
+	ErrInvalidResponse = errors.New("invalid response")
+
+	// ErrMissingContinuation is reported to the WithWarningHook hook when
+	// a full page comes without a continuation token, which may mean that
+	// the API renamed the field and the results are truncated.
+	ErrMissingContinuation = errors.New("missing continuation token")
+
+	ErrLocationNotFound  = errors.New("location not found")
+	ErrAmbiguousLocation = errors.New("ambiguous location")
+
+	// ErrStop can be returned by a callback to stop iterating without
+	// failing.
+	ErrStop = errors.New("stop")
 )
 
 type option func(option *options) error
 
 type options struct {
-	host       string
-	rateLimit  *ratelimit.Limiter
-	httpClient *http.Client
+	host          string
+	failoverHosts []string
+	rateLimit     *ratelimit.Limiter
+	maxJitter     time.Duration
+	httpClient    *http.Client
+	tlsConfig     *tls.Config
+	appTag        string
+	acceptLang    string
+	requestId     func() string
+	defaultParams map[string]string
+	callTimeout   time.Duration
+	allowWrites   bool
+	backoff       BackoffStrategy
+	retries       int
+
+	maxConcurrency int
+	partialResults bool
+	maxPages       int
+	maxResults     int
+	singlePage     bool
+	progress       func(fetched int, lastToken string)
+
+	cache           *cache
+	notFoundCache   *cache
+	httpCache       *httpCache
+	locations       *locationList
+	quota           *quotaState
+	detailsFallback bool
+
+	responseHook func(path string, body []byte)
+	maxBodyLog   int
+	sampleHooks  bool
+	hookSampling float64
+	observer     func(RequestInfo)
+	skippedHook  func(error)
+	warningHook  func(error)
+
+	tweetTransform func([]Tweet) []Tweet
+	userTransform  func([]User) []User
+	mergeMedia     bool
+
+	validateResponses bool
+
+	// builtHttpClient is set when httpClient was made by finish rather
+	// than given with WithHttpClient, so Clone can remake it.
+	builtHttpClient bool
+
+	clock Clock
+	rand  *lockedRand
+	// minInterval, set by WithMinInterval, makes finish build rateLimit
+	// with clock.
+	minInterval time.Duration
 }
 
 func WithHost(host string) option {
@@ -37,6 +123,30 @@ func WithHost(host string) option {
 		}
 
 		option.host = host
+		option.failoverHosts = nil
+		return nil
+	}
+}
+
+// WithHosts sets the host of the API to the first of hosts, and fails over
+// to the others, in order, when a request to it fails with a connection
+// error or a server error. Every request starts with the first host. It
+// replaces any earlier WithHost or WithHosts option.
+func WithHosts(hosts ...string) option {
+	return func(option *options) error {
+		if len(hosts) == 0 {
+			return errors.New("no hosts")
+		}
+
+		for _, host := range hosts {
+			_, err := http.NewRequest("GET", fmt.Sprintf("https://%s", host), nil)
+			if err != nil {
+				return fmt.Errorf("invalid host: %w", err)
+			}
+		}
+
+		option.host = hosts[0]
+		option.failoverHosts = append([]string(nil), hosts[1:]...)
 		return nil
 	}
 }
@@ -44,399 +154,2641 @@ func WithHost(host string) option {
 func WithRateLimit(rl ratelimit.Limiter) option {
 	return func(option *options) error {
 		option.rateLimit = &rl
+		option.minInterval = 0
 		return nil
 	}
 }
 
-func WithHttpClient(hc http.Client) option {
+// WithMinInterval spaces requests at least d apart, without allowing bursts.
+// It replaces the limiter, so if it is combined with WithRateLimit the
+// option given last wins. The limiter keeps time with the WithClock clock.
+func WithMinInterval(d time.Duration) option {
 	return func(option *options) error {
-		option.httpClient = &hc
+		if d <= 0 {
+			return fmt.Errorf("invalid min interval: %s", d)
+		}
+
+		option.rateLimit = nil
+		option.minInterval = d
 		return nil
 	}
 }
 
-type Client struct {
-	apiKey  string
-	options *options
+// WithDisableRateLimit sends requests without any client-side rate
+// limiting, which is the default. It replaces the limiter, so if it is
+// combined with WithRateLimit or WithMinInterval the option given last
+// wins.
+func WithDisableRateLimit() option {
+	return func(option *options) error {
+		rl := ratelimit.NewUnlimited()
+		option.rateLimit = &rl
+		option.minInterval = 0
+		return nil
+	}
 }
 
-func New(apiKey string, opts ...option) (c Client, err error) {
-	o := &options{}
-	for _, opt := range opts {
-		err := opt(o)
-		if err != nil {
-			return c, fmt.Errorf("bad option: %w", err)
+// WithRandomizedRateLimit delays each request, after the rate limiter lets
+// it through, by a random duration of up to maxJitter, so that clients
+// sharing a schedule don't send their requests in bursts. A limiter from
+// go.uber.org/ratelimit, such as WithMinInterval's, counts the delay toward
+// the spacing of the next request, so the average rate is unchanged. The
+// delays are drawn with WithRandSource and waited on the WithClock clock.
+func WithRandomizedRateLimit(maxJitter time.Duration) option {
+	return func(option *options) error {
+		if maxJitter <= 0 {
+			return fmt.Errorf("invalid max jitter: %s", maxJitter)
 		}
+
+		option.maxJitter = maxJitter
+		return nil
 	}
+}
 
-	if o.host == "" {
-		o.host = "twitter154.p.rapidapi.com"
+func WithHttpClient(hc http.Client) option {
+	return func(option *options) error {
+		option.httpClient = &hc
+		option.builtHttpClient = false
+		return nil
 	}
+}
 
-	if o.rateLimit == nil {
-		o.rateLimit = new(ratelimit.Limiter)
-		*o.rateLimit = ratelimit.NewUnlimited()
+// WithTLSConfig sets the TLS configuration of the HTTP client New creates,
+// e.g. to pin certificates. Given to Clone, it makes the copy a new HTTP
+// client with the configuration. It is ignored if WithHttpClient is also
+// given, to New or to Clone.
+func WithTLSConfig(config *tls.Config) option {
+	return func(option *options) error {
+		if config == nil {
+			return errors.New("nil tls config")
+		}
+
+		option.tlsConfig = config.Clone()
+		return nil
 	}
+}
 
-	if o.httpClient == nil {
-		o.httpClient = http.DefaultClient
+// WithAppTag sends tag in the X-App-Tag header of every request, so traffic
+// from several apps sharing one API key can be told apart in RapidAPI's
+// analytics.
+func WithAppTag(tag string) option {
+	return func(option *options) error {
+		if tag == "" || strings.ContainsAny(tag, "\r\n") {
+			return fmt.Errorf("invalid app tag: %q", tag)
+		}
+
+		option.appTag = tag
+		return nil
 	}
+}
 
-	return Client{
-		apiKey:  apiKey,
-		options: o,
-	}, nil
+// WithMaxConcurrency bounds the number of requests GetUsersByUsername, and
+// GetUserTweets with WithParallelWindows, have in flight at once. By default
+// it is unbounded, and only the rate limit paces the requests. Other methods
+// page sequentially, as each continuation token comes with the page before.
+func WithMaxConcurrency(n int) option {
+	return func(option *options) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid max concurrency: %d", n)
+		}
+
+		option.maxConcurrency = n
+		return nil
+	}
 }
 
-type param struct {
-	key   string
-	value any
+// WithAcceptLanguage sends tag, a BCP 47 language tag such as "de" or
+// "pt-BR", in the Accept-Language header of every request, asking for
+// localized content, such as trend names, where the API offers it. It
+// doesn't select which tweets are returned; for that, see
+// WithSearchLanguage.
+func WithAcceptLanguage(tag string) option {
+	return func(option *options) error {
+		if tag == "" || strings.ContainsAny(tag, "\r\n") {
+			return fmt.Errorf("invalid language tag: %q", tag)
+		}
+
+		option.acceptLang = tag
+		return nil
+	}
 }
 
-func (c *Client) buildUrl(p []string) string {
-	return fmt.Sprintf("https://%s/%s", c.options.host, path.Join(p...))
+// WithPartialResults makes paginated methods return the results collected so
+// far alongside the error when fetching a later page fails, instead of
+// discarding them. A failure on the first page still returns no results.
+func WithPartialResults() option {
+	return func(option *options) error {
+		option.partialResults = true
+		return nil
+	}
 }
 
-func (c *Client) buildUrlWithParameters(path []string, params []param) string {
-	uri := c.buildUrl(path)
-	for i, p := range params {
-		separator := "&"
-		if i == 0 {
-			separator = "?"
+// WithCache enables an in-memory cache of users. Users returned by GetUser
+// and GetUserByUsername, and the authors of tweets returned by
+// GetTweetDetails, are cached by both user ID and username for ttl, so later
+// GetUser and GetUserByUsername calls for them make no request. A zero ttl
+// caches forever.
+func WithCache(ttl time.Duration) option {
+	return func(option *options) error {
+		if ttl < 0 {
+			return fmt.Errorf("invalid cache ttl: %s", ttl)
 		}
-		uri = fmt.Sprintf("%s%s%s=%s", uri, separator, p.key, url.QueryEscape(fmt.Sprintf("%v", p.value)))
+
+		option.cache = newCache(ttl)
+		return nil
 	}
-	return uri
 }
 
-func (c *Client) do(req *http.Request) (data []byte, err error) {
-	req.Header.Add("X-RapidAPI-Key", c.apiKey)
-	req.Header.Add("X-RapidAPI-Host", c.options.host)
+// WithNegativeCache remembers for ttl that a tweet wasn't found, e.g.
+// because it was deleted, so that GetTweetDetails, and the methods built on
+// it, fail again with the same error without a request. A zero ttl
+// remembers forever. It is independent of WithCache.
+func WithNegativeCache(ttl time.Duration) option {
+	return func(option *options) error {
+		if ttl < 0 {
+			return fmt.Errorf("invalid negative cache ttl: %s", ttl)
+		}
 
-	(*c.options.rateLimit).Take()
-	resp, err := c.options.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
+		option.notFoundCache = newCache(ttl)
+		return nil
 	}
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("status code %d", resp.StatusCode)
+// WithDefaultParams adds params to the query of every request. A param set
+// by the method itself takes precedence over a default with the same key.
+func WithDefaultParams(params map[string]string) option {
+	return func(option *options) error {
+		option.defaultParams = make(map[string]string, len(params))
+		for k, v := range params {
+			option.defaultParams[k] = v
+		}
+		return nil
 	}
+}
 
-	data, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
+// WithMaxPages caps the number of pages a paginated method fetches. A call
+// that would need more pages fails with ErrTooManyPages.
+func WithMaxPages(n int) option {
+	return func(option *options) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid max pages: %d", n)
+		}
 
-	return data, nil
+		option.maxPages = n
+		return nil
+	}
 }
 
-func (c *Client) get(path []string, params []param) (data []byte, err error) {
-	url := c.buildUrlWithParameters(path, params)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+// WithBasicHTTPCache makes GET requests conditional. The ETag and
+// Last-Modified validators of each response are remembered by URL and sent
+// back as If-None-Match and If-Modified-Since; a 304 Not Modified answer is
+// served from the remembered body. Responses are remembered for the 1024
+// most recently requested URLs.
+func WithBasicHTTPCache() option {
+	return func(option *options) error {
+		option.httpCache = newHTTPCache(_maxHTTPCacheEntries)
+		return nil
 	}
+}
 
-	return c.do(req)
+// WithCallTimeout bounds each request by d when the caller's context has no
+// deadline of its own.
+func WithCallTimeout(d time.Duration) option {
+	return func(option *options) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid call timeout: %s", d)
+		}
+
+		option.callTimeout = d
+		return nil
+	}
 }
 
-type result[T any] interface {
-	Result() T
+// WithGlobalMaxResults caps the number of results any paginated method
+// returns at n; pagination stops once n results have been fetched. A method
+// with its own lower limit, such as SearchCount, keeps that limit.
+func WithGlobalMaxResults(n int) option {
+	return func(option *options) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid max results: %d", n)
+		}
+
+		option.maxResults = n
+		return nil
+	}
 }
 
-func getResult[T any, R result[T]](c *Client, path []string, params []param) (result T, err error) {
-	data, err := c.get(path, params)
-	if err != nil {
-		return result, fmt.Errorf("get: %w", err)
+// WithSinglePage makes every paginated method fetch only the first page, for
+// a predictable cost per call. Later pages can still be fetched through the
+// *Page methods, which expose the continuation token.
+func WithSinglePage() option {
+	return func(option *options) error {
+		option.singlePage = true
+		return nil
 	}
+}
 
-	var r R
-	err = json.Unmarshal(data, &r)
-	if err != nil {
-		return result, fmt.Errorf("unmarshal response: %w", err)
+// WithProgress calls progress after each page a paginated method fetches,
+// with the number of results fetched so far and the continuation token of
+// the next page, which can be saved to resume with a *Page method. It is
+// not called for pages with no results.
+func WithProgress(progress func(fetched int, lastToken string)) option {
+	return func(option *options) error {
+		option.progress = progress
+		return nil
 	}
+}
 
-	return r.Result(), nil
+// WithResponseHook calls hook with the URL path and a copy of the body of
+// every successful response, before the body is unmarshaled.
+func WithResponseHook(hook func(path string, body []byte)) option {
+	return func(option *options) error {
+		option.responseHook = hook
+		return nil
+	}
 }
 
-type resultPaginated[T any] interface {
-	Result() []T
-	Token() string
+// WithSkippedResultHook calls hook for each search result that is skipped
+// because it couldn't be unmarshaled. Search skips malformed results rather
+// than failing the page they are on; without a hook they are dropped
+// silently.
+func WithSkippedResultHook(hook func(error)) option {
+	return func(option *options) error {
+		option.skippedHook = hook
+		return nil
+	}
 }
 
-func getResultPaginated[T any, R resultPaginated[T]](c *Client, path []string, params []param) (results []T, err error) {
-	data, err := c.get(path, params)
-	if err != nil {
-		return nil, fmt.Errorf("get: %w", err)
+// WithWarningHook calls hook with problems that don't fail a call but may
+// make its result incomplete, such as ErrMissingContinuation.
+func WithWarningHook(hook func(error)) option {
+	return func(option *options) error {
+		option.warningHook = hook
+		return nil
 	}
+}
 
-	var r R
-	err = json.Unmarshal(data, &r)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshal response: %w", err)
+// WithResultTransform calls transform on the tweets, or users, that any
+// paginated method or page method returns, and returns what it returns
+// instead, e.g. to redact or drop results. A paginated method calls it
+// once on the results of all pages, a page method or cursor on each page,
+// and neither calls it when the request fails. It runs after the options
+// applied while paging, such as the result limit, WithSearchUntilSeen and
+// WithSearchMinFavorites, and after the WithMergedMedia merge and the
+// flagging of a pinned tweet, but before the options applied to the
+// results as a whole, such as WithMediaOnly, WithMinFavorites,
+// WithSearchLanguageFilter and WithUnfoldEmbedded. One transform can be set
+// for tweets and one for users.
+func WithResultTransform[T Tweet | User](transform func([]T) []T) option {
+	return func(option *options) error {
+		switch transform := any(transform).(type) {
+		case func([]Tweet) []Tweet:
+			option.tweetTransform = transform
+		case func([]User) []User:
+			option.userTransform = transform
+		}
+		return nil
 	}
+}
 
-	path = append(path, "continuation")
-	params = append(params, param{"continuation_token", r.Token()})
+// WithMergedMedia makes every method returning tweets fill in each tweet's
+// empty media fields from its others, as Tweet.MergeMedia does, so that
+// media is complete whichever endpoint a tweet came from. Tweets are merged
+// before the WithResultTransform transform sees them.
+func WithMergedMedia() option {
+	return func(option *options) error {
+		option.mergeMedia = true
+		return nil
+	}
+}
 
-	for len(r.Result()) != 0 {
-		results = append(results, r.Result()...)
-		data, err := c.get(path, params)
-		if err != nil {
-			return nil, fmt.Errorf("get: %w", err)
+// transformResults applies the WithMergedMedia merge and the
+// WithResultTransform transform for T, if any.
+func transformResults[T any](c *Client, results []T) []T {
+	switch r := any(results).(type) {
+	case []Tweet:
+		if c.options.mergeMedia {
+			for i := range r {
+				r[i] = r[i].MergeMedia()
+			}
+		}
+		if c.options.tweetTransform != nil {
+			return any(c.options.tweetTransform(r)).([]T)
 		}
+	case []User:
+		if c.options.userTransform != nil {
+			return any(c.options.userTransform(r)).([]T)
+		}
+	}
+	return results
+}
 
-		err = json.Unmarshal(data, &r)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal response: %w", err)
+// WithMaxBodyLog truncates the bodies passed to the WithResponseHook hook
+// to their first n bytes, followed by "...", to keep debug logs of large
+// responses manageable. The response itself is unmarshaled in full.
+func WithMaxBodyLog(n int) option {
+	return func(option *options) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid max body log: %d", n)
 		}
 
-		params[len(params)-1].value = r.Token()
+		option.maxBodyLog = n
+		return nil
 	}
+}
 
-	return results, nil
+// logBody returns a copy of body for the response hook, truncated as set by
+// WithMaxBodyLog.
+func (c *Client) logBody(body []byte) []byte {
+	if n := c.options.maxBodyLog; n > 0 && len(body) > n {
+		return append(body[:n:n], "..."...)
+	}
+	return append([]byte(nil), body...)
 }
 
-type getUsernameResponse struct {
-	UserId   string `json:"user_id"`
-	Username string `json:"username"`
+// WithHookSampling calls the WithResponseHook and WithObserver hooks for
+// only a random fraction rate, between 0 and 1, of requests, to cut their
+// cost on high-volume clients. Both hooks see the same requests, which
+// are drawn with WithRandSource.
+func WithHookSampling(rate float64) option {
+	return func(option *options) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("invalid hook sampling rate: %v", rate)
+		}
+
+		option.hookSampling = rate
+		option.sampleHooks = true
+		return nil
+	}
 }
 
-func (r getUsernameResponse) Result() string {
-	return r.Username
+// sampled decides whether the hooks see a request, as set by
+// WithHookSampling.
+func (c *Client) sampled() bool {
+	return !c.options.sampleHooks || c.float64() < c.options.hookSampling
 }
 
-var _ result[string] = (*getUsernameResponse)(nil)
+// WithRequestID sends an X-Request-ID header with every request, for
+// correlating logs across systems. The ID is taken from generate, or is a
+// random UUID if generate is nil, and is reported to the WithObserver hook.
+func WithRequestID(generate func() string) option {
+	return func(option *options) error {
+		if generate == nil {
+			generate = newRequestId
+		}
 
-// GetUsername returns a User's username given a user ID.
-func (c *Client) GetUsername(userId string) (username string, err error) {
-	path := []string{"user", "username"}
-	params := []param{
-		{"user_id", userId},
+		option.requestId = generate
+		return nil
 	}
+}
 
-	return getResult[string, getUsernameResponse](c, path, params)
+// WithObserver calls observer after every request, successful or not.
+func WithObserver(observer func(RequestInfo)) option {
+	return func(option *options) error {
+		option.observer = observer
+		return nil
+	}
 }
 
-type getUserResponse = User
+// WithDetailsFallback makes GetTweetDetails, and the methods built on it,
+// look the tweet up through the search endpoint when the details endpoint
+// answers that the rate limit is exceeded. This helps on plans where the
+// two endpoints have separate quotas. The fallback is tried once and never
+// falls back again.
+//
+// The search can only find a tweet that starts a conversation, such as a
+// standalone tweet or the first tweet of a thread; for a reply the
+// fallback fails with ErrNotFound, joined to the rate limit error.
+func WithDetailsFallback() option {
+	return func(option *options) error {
+		option.detailsFallback = true
+		return nil
+	}
+}
 
-func (r getUserResponse) Result() User {
-	return r
+// WithValidateResponses makes a successful response fail with
+// ErrInvalidResponse if a tweet, user or list in it lacks its ID, or a
+// tweet lacks its author's ID. It catches responses that decode without
+// error but are structurally empty, e.g. after the API renames a field,
+// at the cost of failing on records the API legitimately returns
+// incomplete, so it is meant for tests and monitoring.
+func WithValidateResponses() option {
+	return func(option *options) error {
+		option.validateResponses = true
+		return nil
+	}
 }
 
-var _ result[User] = (*getUserResponse)(nil)
+// WithAllowWrites lets the client send requests other than GET and HEAD.
+// The client is read-only by default, and refuses them with ErrReadOnly.
+func WithAllowWrites() option {
+	return func(option *options) error {
+		option.allowWrites = true
+		return nil
+	}
+}
 
-// GetUser returns the public information about a Twitter profile.
-func (c *Client) GetUser(userId string) (user User, err error) {
-	path := []string{"user", "details"}
-	params := []param{
-		{"user_id", userId},
+// WithBackoff retries a GET request that failed because of the rate limit or
+// a server error up to retries times, waiting strategy.NextDelay before each
+// retry on the WithClock clock. The wait ends early if the request's context
+// is done.
+func WithBackoff(strategy BackoffStrategy, retries int) option {
+	return func(option *options) error {
+		if strategy == nil {
+			return errors.New("nil backoff strategy")
+		}
+		if retries <= 0 {
+			return fmt.Errorf("invalid retries: %d", retries)
+		}
+
+		option.backoff = strategy
+		option.retries = retries
+		return nil
 	}
+}
 
-	return getResult[User, getUserResponse](c, path, params)
+type Client struct {
+	apiKey  string
+	options *options
 }
 
-// GetUserByUsername returns the public information about a Twitter profile.
-func (c *Client) GetUserByUsername(username string) (user User, err error) {
-	path := []string{"user", "details"}
-	params := []param{
-		{"username", username},
+func New(apiKey string, opts ...option) (c Client, err error) {
+	o := &options{}
+	err = o.apply(opts)
+	if err != nil {
+		return c, err
 	}
 
-	return getResult[User, getUserResponse](c, path, params)
-}
+	o.finish()
 
-type getUserTweetsOptions struct {
-	includeReplies bool
-	includePinned  bool
+	o.locations = &locationList{}
+	o.quota = &quotaState{}
+
+	return Client{
+		apiKey:  apiKey,
+		options: o,
+	}, nil
 }
 
-type getUserTweetsOption func(*getUserTweetsOptions)
+// The environment variables read by NewFromEnv.
+const (
+	EnvAPIKey = "RAPIDAPI_KEY"
+	EnvHost   = "RAPIDAPI_HOST"
+)
 
-func IncludeReplies() getUserTweetsOption {
-	return func(o *getUserTweetsOptions) {
-		o.includeReplies = true
+// NewFromEnv is New with the API key taken from the RAPIDAPI_KEY
+// environment variable, and the host from RAPIDAPI_HOST if it is set. opts
+// are applied after the host, so WithHost overrides RAPIDAPI_HOST. It fails
+// if RAPIDAPI_KEY is unset or empty.
+func NewFromEnv(opts ...option) (c Client, err error) {
+	apiKey := os.Getenv(EnvAPIKey)
+	if apiKey == "" {
+		return c, fmt.Errorf("environment variable %s is not set", EnvAPIKey)
 	}
-}
 
-func IncludePinned() getUserTweetsOption {
-	return func(o *getUserTweetsOptions) {
-		o.includePinned = true
+	if host := os.Getenv(EnvHost); host != "" {
+		opts = append([]option{WithHost(host)}, opts...)
 	}
-}
 
-type getUserTweetsResponse struct {
-	Results           []Tweet `json:"results"`
-	ContinuationToken string  `json:"continuation_token"`
+	return New(apiKey, opts...)
 }
 
-func (g getUserTweetsResponse) Result() []Tweet {
-	return g.Results
-}
+// finish fills in the defaults of the options that were not given.
+func (o *options) finish() {
+	if o.host == "" {
+		o.host = "twitter154.p.rapidapi.com"
+	}
 
-func (g getUserTweetsResponse) Token() string {
-	return g.ContinuationToken
+	if o.clock == nil {
+		o.clock = realClock{}
+	}
+
+	if o.rateLimit == nil && o.minInterval > 0 {
+		o.rateLimit = new(ratelimit.Limiter)
+		*o.rateLimit = ratelimit.New(1, ratelimit.Per(o.minInterval), ratelimit.WithoutSlack, ratelimit.WithClock(o.clock))
+	}
+
+	if o.rateLimit == nil {
+		o.rateLimit = new(ratelimit.Limiter)
+		*o.rateLimit = ratelimit.NewUnlimited()
+	}
+
+	if o.httpClient == nil && o.tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = o.tlsConfig
+		o.httpClient = &http.Client{Transport: transport}
+		o.builtHttpClient = true
+	}
+
+	if o.httpClient == nil {
+		o.httpClient = http.DefaultClient
+		o.builtHttpClient = true
+	}
+}
+
+// apply applies opts to o, reporting every invalid option.
+func (o *options) apply(opts []option) error {
+	var errs []error
+	for _, opt := range opts {
+		err := opt(o)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("bad option: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Clone returns a copy of the client with opts applied on top of its
+// current options, leaving c unchanged. The copy shares c's caches.
+func (c Client) Clone(opts ...option) (Client, error) {
+	o := *c.options
+	if o.defaultParams != nil {
+		o.defaultParams = make(map[string]string, len(c.options.defaultParams))
+		for k, v := range c.options.defaultParams {
+			o.defaultParams[k] = v
+		}
+	}
+
+	err := o.apply(opts)
+	if err != nil {
+		return Client{}, err
+	}
+
+	if o.builtHttpClient && o.tlsConfig != c.options.tlsConfig {
+		o.httpClient = nil
+	}
+	if o.minInterval > 0 && o.clock != c.options.clock {
+		o.rateLimit = nil
+	}
+	o.finish()
+
+	return Client{
+		apiKey:  c.apiKey,
+		options: &o,
+	}, nil
+}
+
+// param is a query parameter. If the same key is given more than once, the
+// last value wins, except for []string values, which are sent once per
+// element and added to any earlier values of that key.
+type param struct {
+	key   string
+	value any
+}
+
+func (c *Client) buildUrl(p []string) *url.URL {
+	return &url.URL{
+		Scheme: "https",
+		Host:   c.options.host,
+		Path:   "/" + path.Join(p...),
+	}
+}
+
+func (c *Client) buildUrlWithParameters(path []string, params []param) string {
+	uri := c.buildUrl(path)
+
+	query := url.Values{}
+	for _, p := range params {
+		if values, ok := p.value.([]string); ok {
+			for _, v := range values {
+				query.Add(p.key, v)
+			}
+			continue
+		}
+
+		query.Set(p.key, fmt.Sprintf("%v", p.value))
+	}
+	uri.RawQuery = query.Encode()
+
+	return uri.String()
+}
+
+func (c *Client) do(req *http.Request) (data []byte, err error) {
+	if !c.options.allowWrites && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, ErrReadOnly)
+	}
+
+	if c.options.appTag != "" {
+		req.Header.Set("X-App-Tag", c.options.appTag)
+	}
+	if c.options.acceptLang != "" {
+		req.Header.Set("Accept-Language", c.options.acceptLang)
+	}
+	var requestId string
+	if c.options.requestId != nil {
+		requestId = c.options.requestId()
+		req.Header.Set("X-Request-ID", requestId)
+	}
+	req.Header.Set("X-RapidAPI-Key", c.apiKey)
+	req.Header.Set("X-RapidAPI-Host", c.options.host)
+
+	sampled := c.sampled()
+	for attempt := 1; ; attempt++ {
+		data, err := c.sendFailover(req, requestId, sampled)
+		if err == nil && sampled && c.options.responseHook != nil {
+			c.options.responseHook(req.URL.Path, c.logBody(data))
+		}
+		if err == nil || !c.retryable(req, err, attempt) {
+			return data, err
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, err
+		case <-c.options.clock.After(c.options.backoff.NextDelay(attempt)):
+		}
+	}
+}
+
+// sendFailover sends req to the primary host and, if that fails with a
+// connection error or a server error, to each failover host given with
+// WithHosts in turn, until one succeeds.
+func (c *Client) sendFailover(req *http.Request, requestId string, sampled bool) (data []byte, err error) {
+	for i := 0; i <= len(c.options.failoverHosts); i++ {
+		r := req
+		if i > 0 {
+			host := c.options.failoverHosts[i-1]
+			r = req.Clone(req.Context())
+			r.URL.Host = host
+			r.Header.Set("X-RapidAPI-Host", host)
+		}
+
+		start := time.Now()
+		var statusCode int
+		data, statusCode, err = c.send(r)
+
+		if sampled && c.options.observer != nil {
+			c.options.observer(RequestInfo{
+				Method:     r.Method,
+				URL:        r.URL.String(),
+				RequestID:  requestId,
+				StatusCode: statusCode,
+				Duration:   time.Since(start),
+				Err:        err,
+			})
+		}
+
+		if err == nil || req.Context().Err() != nil {
+			return data, err
+		}
+		if statusCode != 0 && !errors.Is(err, ErrServer) {
+			return data, err
+		}
+	}
+
+	return data, err
+}
+
+// retryable reports whether a request that failed with err on the given
+// attempt should be retried under the WithBackoff option.
+func (c *Client) retryable(req *http.Request, err error, attempt int) bool {
+	if c.options.backoff == nil || attempt > c.options.retries {
+		return false
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return IsRateLimited(err) || errors.Is(err, ErrServer)
+}
+
+// send makes a single attempt at req and returns the response body and
+// status code.
+func (c *Client) send(req *http.Request) (data []byte, statusCode int, err error) {
+	var cached validatedResponse
+	var isCached bool
+	if c.options.httpCache != nil && req.Method == http.MethodGet {
+		// req may be a copy of a request to another host, made by
+		// sendFailover, so drop its validators for that host's URL.
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+
+		cached, isCached = c.options.httpCache.get(req.URL.String())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	(*c.options.rateLimit).Take()
+	if max := c.options.maxJitter; max > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, 0, fmt.Errorf("send request: %w", req.Context().Err())
+		case <-c.options.clock.After(time.Duration(c.int63n(int64(max)))):
+		}
+	}
+
+	resp, err := c.options.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if info, ok := parseQuota(resp.Header, time.Now()); ok {
+		c.options.quota.set(info)
+	}
+
+	data, err = readBody(resp)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		return cached.body, resp.StatusCode, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, newAPIError(resp.StatusCode, data)
+	}
+
+	if req.Context().Value(checkErrorBodyKey{}) != nil {
+		if err := newErrorBodyError(resp.StatusCode, data); err != nil {
+			return nil, resp.StatusCode, err
+		}
+	}
+
+	if c.options.httpCache != nil && req.Method == http.MethodGet {
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			c.options.httpCache.set(req.URL.String(), validatedResponse{
+				etag:         etag,
+				lastModified: lastModified,
+				body:         data,
+			})
+		}
+	}
+
+	return data, resp.StatusCode, nil
+}
+
+// _maxBodyPrealloc bounds how much readBody trusts Content-Length.
+const _maxBodyPrealloc = 16 << 20
+
+// readBody reads a response body into a buffer sized from Content-Length
+// when it is known, so large pages are read without repeated regrowth.
+func readBody(resp *http.Response) ([]byte, error) {
+	if resp.ContentLength <= 0 || resp.ContentLength > _maxBodyPrealloc {
+		return io.ReadAll(resp.Body)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+	_, err := buf.ReadFrom(resp.Body)
+	return buf.Bytes(), err
+}
+
+func (c *Client) withDefaultParams(params []param) []param {
+	if len(c.options.defaultParams) == 0 {
+		return params
+	}
+
+	set := make(map[string]bool, len(params))
+	for _, p := range params {
+		set[p.key] = true
+	}
+
+	merged := append([]param(nil), params...)
+	for k, v := range c.options.defaultParams {
+		if !set[k] {
+			merged = append(merged, param{k, v})
+		}
+	}
+	return merged
+}
+
+func (c *Client) get(ctx context.Context, path []string, params []param) (data []byte, err error) {
+	if _, ok := ctx.Deadline(); !ok && c.options.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.callTimeout)
+		defer cancel()
+	}
+
+	url := c.buildUrlWithParameters(path, c.withDefaultParams(params))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	return c.do(req)
+}
+
+type result[T any] interface {
+	Result() T
+}
+
+// checkErrorBodyKey marks the context of a request whose 2xx response body
+// send checks for an error object.
+type checkErrorBodyKey struct{}
+
+// getResultData is get for the functions that decode the response into a
+// result: a 2xx response whose body is an error object, as some endpoints
+// send instead of an error status, is an *APIError, which is retried and
+// failed over like the status its message reports. Raw returns bodies
+// unchecked, since an object with an "error" or "message" field may be
+// what the caller asked for.
+func (c *Client) getResultData(ctx context.Context, path []string, params []param) (data []byte, err error) {
+	return c.get(context.WithValue(ctx, checkErrorBodyKey{}, true), path, params)
+}
+
+func getResult[T any, R result[T]](ctx context.Context, c *Client, path []string, params []param) (result T, err error) {
+	data, err := c.getResultData(ctx, path, params)
+	if err != nil {
+		return result, fmt.Errorf("get: %w", err)
+	}
+
+	var r R
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return result, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	err = c.validate(r.Result())
+	if err != nil {
+		return result, fmt.Errorf("validate response: %w", err)
+	}
+
+	return r.Result(), nil
+}
+
+type resultPaginated[T any] interface {
+	Result() []T
+	Token() string
+}
+
+// The JSON keys accepted for the results and continuation token of a page,
+// in order of preference. The API has renamed keys between versions, so
+// besides the current names the alternatives it has used are accepted.
+var (
+	_resultsKeys    = []string{"results", "data"}
+	_repliesKeys    = []string{"replies", "results", "data"}
+	_favoritersKeys = []string{"favoriters", "results", "data"}
+	_tokenKeys      = []string{"continuation_token", "continuationToken", "cursor"}
+)
+
+// unmarshalPage decodes a page of a paginated response into results and
+// token, taking each from the first of its keys present in data.
+func unmarshalPage[T any](data []byte, resultsKeys []string, results *[]T, token *string) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range resultsKeys {
+		if raw, ok := fields[key]; ok {
+			err := json.Unmarshal(raw, results)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			break
+		}
+	}
+
+	for _, key := range _tokenKeys {
+		if raw, ok := fields[key]; ok {
+			err := json.Unmarshal(raw, token)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// skipper is implemented by responses that skip malformed results instead
+// of failing to unmarshal. Skipped returns an error for each.
+type skipper interface {
+	Skipped() []error
+}
+
+// reportSkipped passes the results skipped in unmarshaling r, if any, to the
+// WithSkippedResultHook hook.
+func (c *Client) reportSkipped(r any) {
+	s, ok := r.(skipper)
+	if !ok || c.options.skippedHook == nil {
+		return
+	}
+
+	for _, err := range s.Skipped() {
+		c.options.skippedHook(err)
+	}
+}
+
+// continuation is how an endpoint takes the token of a later page. The
+// token is always sent as the continuation_token param.
+type continuation int
+
+const (
+	// continuationPath sends the token to the endpoint's "continuation"
+	// sub-path, e.g. user/tweets/continuation.
+	continuationPath continuation = iota
+	// continuationQuery sends the token to the endpoint itself.
+	continuationQuery
+)
+
+func (cont continuation) path(path []string) []string {
+	if cont == continuationPath {
+		return append(path, "continuation")
+	}
+	return path
+}
+
+// pagination tunes a single getResultPaginated call.
+type pagination[T any] struct {
+	// limit caps the number of results; pagination stops once it is
+	// reached. Zero means no cap.
+	limit int
+	// continuation is how the endpoint takes the token of a later page.
+	continuation continuation
+	// until, if set, stops pagination at the first result it matches,
+	// which is dropped along with the results after it.
+	until func(T) bool
+	// keep, if set, drops the results it doesn't match as each page is
+	// fetched, so that they don't count toward limit.
+	keep func(T) bool
+	// notFoundEmpty makes a 404 for the first page an empty result instead
+	// of an error. A 404 for a later page is still an error.
+	notFoundEmpty bool
+	// mark, if set, is called on the results before the WithResultTransform
+	// transform, to flag results by their position, which the transform
+	// may change.
+	mark func([]T)
+}
+
+func getResultPaginated[T any, R resultPaginated[T]](ctx context.Context, c *Client, path []string, params []param, pg pagination[T]) (results []T, err error) {
+	pg.limit = c.resultLimit(pg.limit)
+
+	data, err := c.getResultData(ctx, path, params)
+	if pg.notFoundEmpty && IsNotFound(err) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+
+	var r R
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	c.reportSkipped(r)
+	err = validatePage(c, r.Result())
+	if err != nil {
+		return nil, fmt.Errorf("validate response: %w", err)
+	}
+
+	path = pg.continuation.path(path)
+	params = append(params, param{"continuation_token", nil})
+
+	seen := make(map[string]bool)
+	for pages := 1; len(r.Result()) != 0; pages++ {
+		page := r.Result()
+		stopped := false
+		if pg.until != nil {
+			for i, item := range page {
+				if pg.until(item) {
+					page, stopped = page[:i], true
+					break
+				}
+			}
+		}
+
+		if pg.keep != nil {
+			page = filter(append([]T(nil), page...), pg.keep)
+		}
+
+		results = append(results, page...)
+		limited := pg.limit > 0 && len(results) >= pg.limit
+		if limited {
+			results = results[:pg.limit]
+		}
+
+		if c.options.progress != nil {
+			c.options.progress(len(results), r.Token())
+		}
+
+		if limited || stopped {
+			break
+		}
+
+		if c.options.singlePage {
+			break
+		}
+
+		token := r.Token()
+		if token == "" {
+			if size := pageSize(params); size > 0 && len(r.Result()) >= size {
+				c.warn(fmt.Errorf("full page of %d results without a continuation token: %w", len(r.Result()), ErrMissingContinuation))
+			}
+			break
+		}
+		if seen[token] {
+			return partial(c, results), fmt.Errorf("continuation token %q: %w", token, ErrRepeatedToken)
+		}
+		seen[token] = true
+
+		if c.options.maxPages > 0 && pages >= c.options.maxPages {
+			return partial(c, results), fmt.Errorf("%d pages: %w", pages, ErrTooManyPages)
+		}
+
+		params[len(params)-1].value = token
+		data, err := c.getResultData(ctx, path, params)
+		if err != nil {
+			return partial(c, results), fmt.Errorf("get: %w", err)
+		}
+
+		// Reset r so a page without a token isn't paired with the
+		// previous page's token.
+		var next R
+		err = json.Unmarshal(data, &next)
+		if err != nil {
+			return partial(c, results), fmt.Errorf("unmarshal response: %w", err)
+		}
+		c.reportSkipped(next)
+		err = validatePage(c, next.Result())
+		if err != nil {
+			return partial(c, results), fmt.Errorf("validate response: %w", err)
+		}
+		r = next
+	}
+
+	if pg.mark != nil {
+		pg.mark(results)
+	}
+	results = transformResults(c, results)
+	if results == nil {
+		results = []T{}
+	}
+
+	return results, nil
+}
+
+// resultLimit returns the cap on the results of a paginated call with the
+// given limit, lowered to WithGlobalMaxResults if that is lower. Zero means
+// no cap.
+func (c *Client) resultLimit(limit int) int {
+	if max := c.options.maxResults; max > 0 && (limit == 0 || limit > max) {
+		return max
+	}
+	return limit
+}
+
+// pageSize returns the number of results a full page holds, which is the
+// "limit" param, or 0 if the request doesn't set one.
+func pageSize(params []param) int {
+	size := 0
+	for _, p := range params {
+		if n, ok := p.value.(int); ok && p.key == "limit" {
+			size = n
+		}
+	}
+	return size
+}
+
+// warn passes err to the WithWarningHook hook, if any.
+func (c *Client) warn(err error) {
+	if c.options.warningHook != nil {
+		c.options.warningHook(err)
+	}
+}
+
+// getResultPage fetches the page of a paginated endpoint identified by
+// token, the first page if token is empty, and returns its results and the
+// token of the next page.
+func getResultPage[T any, R resultPaginated[T]](ctx context.Context, c *Client, path []string, params []param, cont continuation, token string, mark func([]T)) (results []T, next string, err error) {
+	if token != "" {
+		path = cont.path(path)
+		params = append(params, param{"continuation_token", token})
+	}
+
+	data, err := c.getResultData(ctx, path, params)
+	if err != nil {
+		return nil, "", fmt.Errorf("get: %w", err)
+	}
+
+	var r R
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		return nil, "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	c.reportSkipped(r)
+	err = validatePage(c, r.Result())
+	if err != nil {
+		return nil, "", fmt.Errorf("validate response: %w", err)
+	}
+
+	results = r.Result()
+	if mark != nil {
+		mark(results)
+	}
+	results = transformResults(c, results)
+	if results == nil {
+		results = []T{}
+	}
+
+	// An empty page ends the pagination even if it carries a token.
+	if len(r.Result()) == 0 {
+		return results, "", nil
+	}
+
+	return results, r.Token(), nil
+}
+
+// partial returns the results to hand back alongside a mid-pagination error.
+func partial[T any](c *Client, results []T) []T {
+	if c.options.partialResults {
+		return results
+	}
+	return nil
+}
+
+// filter returns the items for which keep returns true, reusing the backing
+// array of items.
+func filter[T any](items []T, keep func(T) bool) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+type getUsernameResponse struct {
+	UserId   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (r getUsernameResponse) Result() string {
+	return r.Username
+}
+
+var _ result[string] = (*getUsernameResponse)(nil)
+
+// GetUsername returns a User's username given a user ID.
+func (c *Client) GetUsername(userId string) (username string, err error) {
+	path := []string{"user", "username"}
+	params := []param{
+		{"user_id", userId},
+	}
+
+	return getResult[string, getUsernameResponse](context.Background(), c, path, params)
+}
+
+// Ping makes a cheap request to check that the API key is accepted and the
+// API is reachable. A rejected key or exhausted quota is reported as an
+// *APIError carrying the status code.
+func (c *Client) Ping(ctx context.Context) error {
+	path := []string{"user", "username"}
+	params := []param{
+		{"user_id", _pingUserId},
+	}
+
+	_, err := getResult[string, getUsernameResponse](ctx, c, path, params)
+	if err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	return nil
+}
+
+// Raw makes an authenticated GET request to an endpoint the client doesn't
+// cover yet, given by its path segments, and returns the response body
+// undecoded. It goes through the same machinery as every other
+// method, so the rate limit, default params, hooks and options such as
+// WithBackoff apply, and a non-2xx response is an *APIError. A 2xx body is
+// returned as is, even if it looks like an error object.
+func (c *Client) Raw(ctx context.Context, path []string, params map[string]string) ([]byte, error) {
+	ps := make([]param, 0, len(params))
+	for k, v := range params {
+		ps = append(ps, param{k, v})
+	}
+
+	data, err := c.get(ctx, path, ps)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+
+	return data, nil
+}
+
+type getUserResponse = User
+
+func (r getUserResponse) Result() User {
+	return r
+}
+
+var _ result[User] = (*getUserResponse)(nil)
+
+// GetUser returns the public information about a Twitter profile.
+func (c *Client) GetUser(userId string) (user User, err error) {
+	if user, ok := c.cachedUser(userId); ok {
+		return user, nil
+	}
+
+	path := []string{"user", "details"}
+	params := []param{
+		{"user_id", userId},
+	}
+
+	user, err = getResult[User, getUserResponse](context.Background(), c, path, params)
+	if err != nil {
+		return user, err
+	}
+
+	c.cacheUser(user)
+	return user, nil
+}
+
+// GetUserByUsername returns the public information about a Twitter profile.
+func (c *Client) GetUserByUsername(username string) (user User, err error) {
+	if user, ok := c.cachedUserByUsername(username); ok {
+		return user, nil
+	}
+
+	path := []string{"user", "details"}
+	params := []param{
+		{"username", username},
+	}
+
+	user, err = getResult[User, getUserResponse](context.Background(), c, path, params)
+	if err != nil {
+		return user, err
+	}
+
+	c.cacheUser(user)
+	return user, nil
+}
+
+// GetUsersByUsername looks up several profiles concurrently, within the
+// client's rate limit and the WithMaxConcurrency bound. Usernames may carry
+// a leading "@". users and errs are in the order of usernames: errs[i] is
+// the error looking up usernames[i] failed with, in which case users[i] is
+// the zero User, and nil otherwise.
+func (c *Client) GetUsersByUsername(usernames []string) (users []User, errs []error) {
+	users = make([]User, len(usernames))
+	errs = make([]error, len(usernames))
+
+	var sem chan struct{}
+	if c.options.maxConcurrency > 0 {
+		sem = make(chan struct{}, c.options.maxConcurrency)
+	}
+
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+
+			username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+			users[i], errs[i] = c.GetUserByUsername(username)
+		}(i, username)
+	}
+	wg.Wait()
+
+	return users, errs
+}
+
+type getUserTweetsOptions struct {
+	includeReplies bool
+	includePinned  bool
+	sinceId        string
+	maxId          string
+	mediaOnly      bool
+	languages      languageSet
+	mediaSelection MediaSelection
+	notFoundEmpty  bool
+	engagement     engagementFilter
+	windows        int
+}
+
+type getUserTweetsOption func(*getUserTweetsOptions)
+
+func IncludeReplies() getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.includeReplies = true
+	}
+}
+
+func IncludePinned() getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.includePinned = true
+	}
+}
+
+// WithSinceId returns only tweets newer than the tweet with the given ID.
+// The bound is sent with every page request, so it holds across
+// continuation pages too.
+func WithSinceId(id string) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.sinceId = id
+	}
+}
+
+// WithMaxId returns only tweets at least as old as the tweet with the given
+// ID. Like WithSinceId, it is sent with every page request.
+func WithMaxId(id string) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.maxId = id
+	}
+}
+
+// WithMediaOnly keeps only tweets with photos or videos attached. The API
+// has no such filter, so it is applied client-side after all pages have
+// been fetched.
+func WithMediaOnly() getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.mediaOnly = true
+	}
+}
+
+// WithLanguageFilter keeps only tweets in one of the languages with the
+// given codes, such as "en", compared case-insensitively with
+// Tweet.Language. The API has no such filter, so it is applied client-side
+// after all pages have been fetched. Without codes it filters nothing.
+func WithLanguageFilter(codes ...string) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		if len(codes) == 0 {
+			o.languages = nil
+			return
+		}
+		o.languages = newLanguageSet(codes)
+	}
+}
+
+// WithTimelineMediaSelection sets each tweet's SelectedMediaUrl to the
+// media variant chosen by sel, as WithMediaSelection does for
+// GetTweetDetails.
+func WithTimelineMediaSelection(sel MediaSelection) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.mediaSelection = sel
+	}
+}
+
+// WithTreatNotFoundAsEmpty makes a timeline the API answers with 404 Not
+// Found, as it does for some deleted and recreated accounts, an empty
+// timeline instead of an error, so batch jobs can carry on. A 404 for a
+// later page is still an error.
+func WithTreatNotFoundAsEmpty() getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.notFoundEmpty = true
+	}
+}
+
+// WithMinFavorites keeps only tweets liked at least n times. The API has
+// no such filter, so it is applied client-side after all pages have been
+// fetched, and doesn't reduce the number of requests.
+func WithMinFavorites(n int) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.engagement.minFavorites = n
+	}
+}
+
+// WithMinRetweets keeps only tweets retweeted at least n times. Like
+// WithMinFavorites, it is applied client-side.
+func WithMinRetweets(n int) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.engagement.minRetweets = n
+	}
+}
+
+// WithParallelWindows splits the IDs between WithSinceId and WithMaxId,
+// which it requires, into n windows and fetches them concurrently, within
+// the client's rate limit and the WithMaxConcurrency bound. Since tweet IDs
+// grow with time, each window holds a span of the timeline; its pages are
+// still fetched in order, and the windows are joined newest first, as a
+// sequential fetch returns them. Only the user tweets endpoint takes
+// since_id and max_id, so no other method can be fetched in windows.
+func WithParallelWindows(n int) getUserTweetsOption {
+	return func(o *getUserTweetsOptions) {
+		o.windows = n
+	}
+}
+
+// engagementFilter keeps tweets with at least the given engagement. Zero
+// minimums keep everything.
+type engagementFilter struct {
+	minFavorites int
+	minRetweets  int
+}
+
+func (f engagementFilter) active() bool {
+	return f.minFavorites > 0 || f.minRetweets > 0
+}
+
+func (f engagementFilter) keep(t Tweet) bool {
+	return int(t.FavoriteCount) >= f.minFavorites && int(t.RetweetCount) >= f.minRetweets
+}
+
+// languageSet is a set of language codes, lowercased.
+type languageSet map[string]bool
+
+func newLanguageSet(codes []string) languageSet {
+	set := make(languageSet, len(codes))
+	for _, code := range codes {
+		set[strings.ToLower(code)] = true
+	}
+	return set
+}
+
+func (s languageSet) keep(t Tweet) bool {
+	return s[strings.ToLower(t.Language)]
+}
+
+type getUserTweetsResponse struct {
+	Results           []Tweet `json:"results"`
+	ContinuationToken string  `json:"continuation_token"`
+}
+
+func (g *getUserTweetsResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _resultsKeys, &g.Results, &g.ContinuationToken)
+}
+
+func (g getUserTweetsResponse) Result() []Tweet {
+	return g.Results
+}
+
+func (g getUserTweetsResponse) Token() string {
+	return g.ContinuationToken
+}
+
+var _ resultPaginated[Tweet] = (*getUserTweetsResponse)(nil)
+
+func userTweetsRequest(userId string, opts []getUserTweetsOption) (path []string, params []param, o getUserTweetsOptions) {
+	path = []string{"user", "tweets"}
+	params = []param{
+		{"user_id", userId},
+		{"limit", _pageLimit},
+	}
+
+	o = getUserTweetsOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.includeReplies {
+		params = append(params, param{"include_replies", "true"})
+	} else {
+		params = append(params, param{"include_replies", "false"})
+	}
+
+	if o.includePinned {
+		params = append(params, param{"include_pinned", "true"})
+	} else {
+		params = append(params, param{"include_pinned", "false"})
+	}
+
+	if o.sinceId != "" {
+		params = append(params, param{"since_id", o.sinceId})
+	}
+
+	if o.maxId != "" {
+		params = append(params, param{"max_id", o.maxId})
+	}
+
+	return path, params, o
+}
+
+// mark returns markPinned if the pinned tweet was asked for and tweets start
+// a timeline, which is where the API puts it, and nil otherwise.
+func (o getUserTweetsOptions) mark(first bool) func([]Tweet) {
+	if o.includePinned && first {
+		return markPinned
+	}
+	return nil
+}
+
+// markPinned guesses the first tweet of a timeline's first page is pinned
+// if it is older than the tweet after it, since timelines are otherwise
+// newest first. The API has no marker to tell for sure.
+func markPinned(tweets []Tweet) {
+	if len(tweets) >= 2 && tweets[0].Timestamp < tweets[1].Timestamp {
+		tweets[0].LikelyPinned = true
+	}
+}
+
+// apply applies the client-side filters selected by the options.
+func (o getUserTweetsOptions) apply(tweets []Tweet) []Tweet {
+	if !o.includeReplies {
+		tweets = dropReplies(tweets)
+	}
+
+	if o.mediaOnly {
+		tweets = filter(tweets, Tweet.HasMedia)
+	}
+
+	if o.languages != nil {
+		tweets = filter(tweets, o.languages.keep)
+	}
+
+	if o.engagement.active() {
+		tweets = filter(tweets, o.engagement.keep)
+	}
+
+	if o.mediaSelection != 0 {
+		for i := range tweets {
+			tweets[i].SelectedMediaUrl = tweets[i].selectMedia(o.mediaSelection)
+		}
+	}
+
+	return tweets
+}
+
+// dropReplies removes replies from a timeline, as some plans return them
+// despite include_replies=false. Self-replies and replies to another tweet
+// in tweets are kept, since a user's timeline includes their own threads,
+// whose tweets may be split across pages.
+func dropReplies(tweets []Tweet) []Tweet {
+	ids := make(map[string]bool, len(tweets))
+	for _, t := range tweets {
+		ids[t.TweetId] = true
+	}
+
+	return filter(tweets, func(t Tweet) bool {
+		return t.InReplyToStatusId == "" || t.IsSelfReply() || ids[t.InReplyToStatusId]
+	})
+}
+
+// GetUserTweets returns a list of user's tweets.
+func (c *Client) GetUserTweets(userId string, opts ...getUserTweetsOption) (tweets []Tweet, err error) {
+	path, params, o := userTweetsRequest(userId, opts)
+
+	if o.windows != 0 {
+		tweets, err = c.getUserTweetsWindows(path, params, o)
+	} else {
+		tweets, err = getResultPaginated[Tweet, getUserTweetsResponse](context.Background(), c, path, params, pagination[Tweet]{notFoundEmpty: o.notFoundEmpty, mark: o.mark(true)})
+	}
+
+	tweets = o.apply(tweets)
+	return tweets, err
+}
+
+// getUserTweetsWindows fetches the IDs in (o.sinceId, o.maxId] as o.windows
+// windows of about equal width, each paged on its own goroutine. Window k
+// runs from bounds[k+1], exclusive like since_id, to bounds[k], inclusive
+// like max_id, so the windows neither overlap nor leave gaps.
+func (c *Client) getUserTweetsWindows(path []string, params []param, o getUserTweetsOptions) ([]Tweet, error) {
+	if o.windows < 0 {
+		return nil, fmt.Errorf("invalid number of windows: %d", o.windows)
+	}
+	if o.sinceId == "" || o.maxId == "" {
+		return nil, errors.New("parallel windows need both a since and a max ID")
+	}
+	since, err := strconv.ParseUint(o.sinceId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid since ID %q: %w", o.sinceId, err)
+	}
+	max, err := strconv.ParseUint(o.maxId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max ID %q: %w", o.maxId, err)
+	}
+	if since >= max {
+		return nil, fmt.Errorf("since ID %d is not below max ID %d", since, max)
+	}
+
+	n := uint64(o.windows)
+	if n > max-since {
+		n = max - since
+	}
+	width := (max - since) / n
+	bounds := make([]uint64, n+1)
+	for k := range bounds {
+		bounds[k] = max - width*uint64(k)
+	}
+	bounds[n] = since
+
+	// Only the newest window asks for the pinned tweet, so it isn't
+	// returned once per window.
+	var base []param
+	for _, p := range params {
+		switch p.key {
+		case "since_id", "max_id", "include_pinned":
+		default:
+			base = append(base, p)
+		}
+	}
+
+	results := make([][]Tweet, n)
+	errs := make([]error, n)
+
+	var sem chan struct{}
+	if c.options.maxConcurrency > 0 {
+		sem = make(chan struct{}, c.options.maxConcurrency)
+	}
+
+	var wg sync.WaitGroup
+	for k := 0; k < int(n); k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+
+			pinned := "false"
+			if k == 0 && o.includePinned {
+				pinned = "true"
+			}
+			windowParams := append(base[:len(base):len(base)],
+				param{"include_pinned", pinned},
+				param{"since_id", strconv.FormatUint(bounds[k+1], 10)},
+				param{"max_id", strconv.FormatUint(bounds[k], 10)},
+			)
+			results[k], errs[k] = getResultPaginated[Tweet, getUserTweetsResponse](context.Background(), c, path, windowParams, pagination[Tweet]{notFoundEmpty: o.notFoundEmpty, mark: o.mark(k == 0)})
+			if errs[k] != nil {
+				errs[k] = fmt.Errorf("window %d: %w", k, errs[k])
+			}
+		}(k)
+	}
+	wg.Wait()
+
+	var tweets []Tweet
+	for _, r := range results {
+		tweets = append(tweets, r...)
+	}
+	if limit := c.resultLimit(0); limit > 0 && len(tweets) > limit {
+		tweets = tweets[:limit]
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return partial(c, tweets), err
+	}
+	return tweets, nil
+}
+
+// GetUserTweetsWithProfile returns a user's profile along with their tweets,
+// as GetUserTweets does. The profile is taken from the user's first tweet,
+// saving a request; only if no tweet carries it, e.g. because the timeline
+// is empty, is it fetched with GetUser.
+func (c *Client) GetUserTweetsWithProfile(userId string, opts ...getUserTweetsOption) (user User, tweets []Tweet, err error) {
+	tweets, err = c.GetUserTweets(userId, opts...)
+	if err != nil {
+		return user, tweets, err
+	}
+
+	for _, t := range tweets {
+		if t.User.UserId == userId {
+			c.cacheUser(t.User)
+			return t.User, tweets, nil
+		}
+	}
+
+	user, err = c.GetUser(userId)
+	if err != nil {
+		return user, nil, fmt.Errorf("get user: %w", err)
+	}
+
+	return user, tweets, nil
+}
+
+// GetUserTweetsPage returns a single page of a user's tweets and the token
+// of the next page. Pass an empty token for the first page; an empty next
+// token means there are no more pages.
+func (c *Client) GetUserTweetsPage(userId string, token string, opts ...getUserTweetsOption) (tweets []Tweet, next string, err error) {
+	path, params, o := userTweetsRequest(userId, opts)
+	return c.userTweetsPage(context.Background(), path, params, continuationPath, token, o)
+}
+
+// userTweetsPage fetches the page of a user's tweets with the given token
+// and processes it as GetUserTweets does its results.
+func (c *Client) userTweetsPage(ctx context.Context, path []string, params []param, cont continuation, token string, o getUserTweetsOptions) (tweets []Tweet, next string, err error) {
+	tweets, next, err = getResultPage[Tweet, getUserTweetsResponse](ctx, c, path, params, cont, token, o.mark(token == ""))
+	if o.notFoundEmpty && token == "" && IsNotFound(err) {
+		return []Tweet{}, "", nil
+	}
+
+	tweets = o.apply(tweets)
+	return tweets, next, err
+}
+
+type getUserFollowsResponse struct {
+	Results           []User `json:"results"`
+	ContinuationToken string `json:"continuation_token"`
+}
+
+func (g *getUserFollowsResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _resultsKeys, &g.Results, &g.ContinuationToken)
+}
+
+func (g getUserFollowsResponse) Result() []User {
+	return g.Results
+}
+
+func (g getUserFollowsResponse) Token() string {
+	return g.ContinuationToken
 }
 
-var _ resultPaginated[Tweet] = (*getUserTweetsResponse)(nil)
+var _ resultPaginated[User] = (*getUserFollowsResponse)(nil)
 
-// GetUserTweets returns a list of user's tweets.
-func (c *Client) GetUserTweets(userId string, opts ...getUserTweetsOption) (tweets []Tweet, err error) {
-	path := []string{"user", "tweets"}
+type getUserFollowsOptions struct {
+	verifiedOnly bool
+}
+
+type getUserFollowsOption func(*getUserFollowsOptions)
+
+// WithVerifiedOnly keeps only users that are verified or blue verified.
+// The API has no such filter, so it is applied client-side after all pages
+// have been fetched.
+func WithVerifiedOnly() getUserFollowsOption {
+	return func(o *getUserFollowsOptions) {
+		o.verifiedOnly = true
+	}
+}
+
+func userFollowsRequest(path []string, userId string, opts []getUserFollowsOption) (_ []string, params []param, o getUserFollowsOptions) {
+	params = []param{
+		{"user_id", userId},
+		{"limit", _pageLimit},
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return path, params, o
+}
+
+// apply applies the client-side filters selected by the options.
+func (o getUserFollowsOptions) apply(users []User) []User {
+	if o.verifiedOnly {
+		users = filter(users, func(u User) bool {
+			return u.IsVerified || u.IsBlueVerified
+		})
+	}
+
+	return users
+}
+
+func (c *Client) getUserFollows(path []string, userId string, opts []getUserFollowsOption) (users []User, err error) {
+	path, params, o := userFollowsRequest(path, userId, opts)
+
+	users, err = getResultPaginated[User, getUserFollowsResponse](context.Background(), c, path, params, pagination[User]{})
+	users = o.apply(users)
+	return users, err
+}
+
+// GetUserFollowing returns a list of user's following.
+func (c *Client) GetUserFollowing(userId string, opts ...getUserFollowsOption) (following []User, err error) {
+	return c.getUserFollows([]string{"user", "following"}, userId, opts)
+}
+
+// GetUserFollowers returns a list of user's followers.
+func (c *Client) GetUserFollowers(userId string, opts ...getUserFollowsOption) (followers []User, err error) {
+	return c.getUserFollows([]string{"user", "followers"}, userId, opts)
+}
+
+// GetUserFollowingPage returns a single page of a user's following, the
+// token of the next page, and the total number of accounts the user
+// follows, for showing progress. Pass an empty token for the first page; an
+// empty next token means there are no more pages. The total is reported
+// with the first page only, and is 0 for later pages, so the profile is
+// fetched once per listing. It is the FollowingCount of the user's
+// profile, fetched with GetUser; it is reported by the API, may be
+// approximate, and need not match the number of users the pages add up
+// to, e.g. when WithVerifiedOnly is given.
+func (c *Client) GetUserFollowingPage(userId string, token string, opts ...getUserFollowsOption) (following []User, next string, total int, err error) {
+	return c.getUserFollowsPage([]string{"user", "following"}, userId, token, opts, func(u User) Count {
+		return u.FollowingCount
+	})
+}
+
+// GetUserFollowersPage is GetUserFollowingPage for a user's followers. The
+// total, reported with the first page only, is the FollowerCount of the
+// user's profile.
+func (c *Client) GetUserFollowersPage(userId string, token string, opts ...getUserFollowsOption) (followers []User, next string, total int, err error) {
+	return c.getUserFollowsPage([]string{"user", "followers"}, userId, token, opts, func(u User) Count {
+		return u.FollowerCount
+	})
+}
+
+func (c *Client) getUserFollowsPage(path []string, userId string, token string, opts []getUserFollowsOption, count func(User) Count) (users []User, next string, total int, err error) {
+	if token == "" {
+		user, err := c.GetUser(userId)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("get user: %w", err)
+		}
+		total = int(count(user))
+	}
+
+	path, params, o := userFollowsRequest(path, userId, opts)
+
+	users, next, err = getResultPage[User, getUserFollowsResponse](context.Background(), c, path, params, continuationPath, token, nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return o.apply(users), next, total, nil
+}
+
+// GetUserLikes returns a list of user's likes given a user ID
+func (c *Client) GetUserLikes(userId string) (likes []Tweet, err error) {
+	return likes, ErrNotImplemented
+}
+
+type getUserMediaResponse struct {
+	Results           []Tweet `json:"results"`
+	ContinuationToken string  `json:"continuation_token"`
+}
+
+func (g *getUserMediaResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _resultsKeys, &g.Results, &g.ContinuationToken)
+}
+
+func (g getUserMediaResponse) Result() []Tweet {
+	return g.Results
+}
+
+func (g getUserMediaResponse) Token() string {
+	return g.ContinuationToken
+}
+
+var _ resultPaginated[Tweet] = (*getUserMediaResponse)(nil)
+
+// GetUserMedia returns a list of user's tweets with media given a user ID
+func (c *Client) GetUserMedia(userId string) (media []Tweet, err error) {
+	path := []string{"user", "medias"}
 	params := []param{
 		{"user_id", userId},
 		{"limit", _pageLimit},
 	}
 
-	o := getUserTweetsOptions{}
-	for _, opt := range opts {
-		opt(&o)
-	}
+	return getResultPaginated[Tweet, getUserMediaResponse](context.Background(), c, path, params, pagination[Tweet]{})
+}
+
+// GetUserMediaGrouped returns the media of a user's tweets split into photos
+// and videos, the latter including animated GIFs.
+func (c *Client) GetUserMediaGrouped(userId string) (photos, videos []Media, err error) {
+	tweets, err := c.GetUserMedia(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	photos, videos = []Media{}, []Media{}
+	for _, t := range tweets {
+		for _, m := range t.ExtendedEntities.Media {
+			switch m.Type {
+			case MediaTypePhoto:
+				photos = append(photos, m)
+			case MediaTypeVideo, MediaTypeAnimatedGif:
+				videos = append(videos, m)
+			}
+		}
+	}
+
+	return photos, videos, nil
+}
+
+type getTweetRepliesResponse struct {
+	Replies           []Tweet `json:"replies"`
+	ContinuationToken string  `json:"continuation_token"`
+}
+
+func (g *getTweetRepliesResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _repliesKeys, &g.Replies, &g.ContinuationToken)
+}
+
+func (g getTweetRepliesResponse) Result() []Tweet {
+	return g.Replies
+}
+
+func (g getTweetRepliesResponse) Token() string {
+	return g.ContinuationToken
+}
+
+var _ resultPaginated[Tweet] = (*getTweetRepliesResponse)(nil)
+
+// ReplySort is the order of the replies returned by GetTweetReplies.
+type ReplySort int
+
+const (
+	// ReplySortRelevance keeps the API's order, which ranks replies by
+	// relevance as the site does.
+	ReplySortRelevance ReplySort = iota
+	// ReplySortRecency puts the newest replies first.
+	ReplySortRecency
+	// ReplySortLikes puts the most liked replies first.
+	ReplySortLikes
+)
+
+type getTweetRepliesOptions struct {
+	sort       ReplySort
+	authorOnly bool
+
+	// authorId is the root tweet's author, looked up for authorOnly.
+	authorId string
+}
+
+type getTweetRepliesOption func(*getTweetRepliesOptions)
+
+// WithReplySort orders the replies by sort. The API has no sort parameter,
+// so any order other than ReplySortRelevance is applied client-side after
+// all pages have been fetched, or to each page on its own for a cursor.
+func WithReplySort(sort ReplySort) getTweetRepliesOption {
+	return func(o *getTweetRepliesOptions) {
+		o.sort = sort
+	}
+}
+
+// WithAuthorRepliesOnly keeps only the replies posted by the author of the
+// tweet replied to, which continue the author's thread. Finding the author
+// costs a GetTweetDetails request per call. The filter is applied
+// client-side, and only by GetTweetReplies and GetTweetRepliesPage;
+// TweetRepliesCursor ignores it.
+func WithAuthorRepliesOnly() getTweetRepliesOption {
+	return func(o *getTweetRepliesOptions) {
+		o.authorOnly = true
+	}
+}
+
+func tweetRepliesRequest(tweetId string, opts []getTweetRepliesOption) (path []string, params []param, o getTweetRepliesOptions) {
+	path = []string{"tweet", "replies"}
+	params = []param{
+		{"tweet_id", tweetId},
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return path, params, o
+}
+
+func (o getTweetRepliesOptions) apply(replies []Tweet) []Tweet {
+	if o.authorId != "" {
+		replies = filter(replies, func(t Tweet) bool {
+			return t.User.UserId == o.authorId
+		})
+	}
+
+	switch o.sort {
+	case ReplySortRecency:
+		sort.SliceStable(replies, func(i, j int) bool {
+			return replies[i].Timestamp > replies[j].Timestamp
+		})
+	case ReplySortLikes:
+		sort.SliceStable(replies, func(i, j int) bool {
+			return replies[i].FavoriteCount > replies[j].FavoriteCount
+		})
+	}
+
+	return replies
+}
+
+// GetTweetReplies returns a list of replies to a tweet.
+func (c *Client) GetTweetReplies(tweetId string, opts ...getTweetRepliesOption) (replies []Tweet, err error) {
+	ctx := context.Background()
+	path, params, o := tweetRepliesRequest(tweetId, opts)
+
+	if o.authorOnly {
+		root, err := c.getTweetDetails(ctx, tweetId)
+		if err != nil {
+			return nil, fmt.Errorf("get author: %w", err)
+		}
+		o.authorId = root.User.UserId
+	}
+
+	replies, err = getResultPaginated[Tweet, getTweetRepliesResponse](ctx, c, path, params, pagination[Tweet]{})
+	if err != nil {
+		return replies, err
+	}
+
+	return o.apply(replies), nil
+}
+
+// GetTweetRepliesPage returns a single page of the replies to a tweet and
+// the token of the next page. Pass an empty token for the first page; an
+// empty next token means there are no more pages. It accepts the same
+// options as GetTweetReplies, applied to the page on its own.
+func (c *Client) GetTweetRepliesPage(tweetId string, token string, opts ...getTweetRepliesOption) (replies []Tweet, next string, err error) {
+	ctx := context.Background()
+	path, params, o := tweetRepliesRequest(tweetId, opts)
+
+	if o.authorOnly {
+		root, err := c.getTweetDetails(ctx, tweetId)
+		if err != nil {
+			return nil, "", fmt.Errorf("get author: %w", err)
+		}
+		o.authorId = root.User.UserId
+	}
+
+	replies, next, err = getResultPage[Tweet, getTweetRepliesResponse](ctx, c, path, params, continuationPath, token, nil)
+	if err != nil {
+		return replies, next, err
+	}
+
+	return o.apply(replies), next, nil
+}
+
+type getTweetDetailsResponse = Tweet
+
+func (g getTweetDetailsResponse) Result() Tweet {
+	return g
+}
+
+var _ result[Tweet] = (*getTweetDetailsResponse)(nil)
+
+type getTweetDetailsOptions struct {
+	quotedTweet    bool
+	mediaSelection MediaSelection
+}
+
+type getTweetDetailsOption func(*getTweetDetailsOptions)
+
+// WithQuotedTweet also fetches the tweet quoted by the tweet, if any, into
+// its QuotedStatus field. This costs a second request. If the quoted tweet
+// has been deleted, QuotedStatus is left nil.
+func WithQuotedTweet() getTweetDetailsOption {
+	return func(o *getTweetDetailsOptions) {
+		o.quotedTweet = true
+	}
+}
+
+// WithMediaSelection sets the tweet's SelectedMediaUrl to the media
+// variant chosen by sel, so it needn't be chosen again downstream. It is
+// left empty if the tweet has no such media.
+func WithMediaSelection(sel MediaSelection) getTweetDetailsOption {
+	return func(o *getTweetDetailsOptions) {
+		o.mediaSelection = sel
+	}
+}
+
+// GetTweetDetails returns general information about a tweet.
+func (c *Client) GetTweetDetails(tweetId string, opts ...getTweetDetailsOption) (tweet Tweet, err error) {
+	return c.getTweetDetailsWithOptions(context.Background(), tweetId, opts)
+}
+
+// GetTweetByURL returns general information about the tweet a twitter.com,
+// mobile.twitter.com or x.com link points to.
+func (c *Client) GetTweetByURL(ctx context.Context, url string, opts ...getTweetDetailsOption) (tweet Tweet, err error) {
+	tweetId, err := ParseTweetURL(url)
+	if err != nil {
+		return tweet, err
+	}
+
+	return c.getTweetDetailsWithOptions(ctx, tweetId, opts)
+}
+
+func (c *Client) getTweetDetailsWithOptions(ctx context.Context, tweetId string, opts []getTweetDetailsOption) (tweet Tweet, err error) {
+	o := getTweetDetailsOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tweet, err = c.getTweetDetails(ctx, tweetId)
+	if err != nil {
+		return tweet, err
+	}
+
+	if o.quotedTweet && tweet.QuotedStatusId != "" && tweet.QuotedStatus == nil {
+		quoted, err := c.getTweetDetails(ctx, tweet.QuotedStatusId)
+		switch {
+		case err == nil:
+			tweet.QuotedStatus = &quoted
+		case !IsNotFound(err):
+			return tweet, fmt.Errorf("get quoted tweet %s: %w", tweet.QuotedStatusId, err)
+		}
+	}
+
+	tweet.SelectedMediaUrl = tweet.selectMedia(o.mediaSelection)
+	return tweet, nil
+}
+
+// IsTweetAvailable reports whether a tweet can still be fetched. It returns
+// false, without an error, if the API answers that the tweet wasn't found,
+// e.g. because it was deleted or its author's account is gone, and an error
+// for any other failure, which says nothing about the tweet.
+func (c *Client) IsTweetAvailable(tweetId string) (available bool, err error) {
+	_, err = c.getTweetDetails(context.Background(), tweetId)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetTweetWithParent returns general information about a tweet and, if it
+// is a reply, about the tweet it replies to. parent is nil for tweets that
+// aren't replies, and for replies whose parent is not found, e.g. because
+// it was deleted; other failures to get the parent are errors.
+func (c *Client) GetTweetWithParent(tweetId string) (tweet Tweet, parent *Tweet, err error) {
+	ctx := context.Background()
+
+	tweet, err = c.getTweetDetails(ctx, tweetId)
+	if err != nil {
+		return tweet, nil, err
+	}
+
+	if tweet.InReplyToStatusId == "" {
+		return tweet, nil, nil
+	}
+
+	p, err := c.getTweetDetails(ctx, tweet.InReplyToStatusId)
+	if IsNotFound(err) {
+		return tweet, nil, nil
+	}
+	if err != nil {
+		return tweet, nil, fmt.Errorf("get parent %s: %w", tweet.InReplyToStatusId, err)
+	}
+
+	return tweet, &p, nil
+}
+
+func (c *Client) getTweetDetails(ctx context.Context, tweetId string) (tweet Tweet, err error) {
+	if err := c.cachedNotFound(tweetId); err != nil {
+		return tweet, err
+	}
+
+	path := []string{"tweet", "details"}
+	params := []param{
+		{"tweet_id", tweetId},
+	}
+
+	tweet, err = getResult[Tweet, getTweetDetailsResponse](ctx, c, path, params)
+	if IsNotFound(err) {
+		c.cacheNotFound(tweetId, err)
+	}
+	if err != nil && c.options.detailsFallback && IsRateLimited(err) {
+		var fallbackErr error
+		tweet, fallbackErr = c.searchTweet(ctx, tweetId)
+		if fallbackErr != nil {
+			return tweet, errors.Join(err, fmt.Errorf("search fallback: %w", fallbackErr))
+		}
+		err = nil
+	}
+	if err != nil {
+		return tweet, err
+	}
+
+	if c.options.mergeMedia {
+		tweet = tweet.MergeMedia()
+	}
+
+	c.cacheUser(tweet.User)
+	return tweet, nil
+}
+
+// searchTweet finds a tweet by ID through the search endpoint, by searching
+// the conversation with the tweet's ID. A conversation's ID is the ID of
+// the tweet that started it, so this finds only tweets that start a
+// conversation; the search has no operator matching a tweet by its own
+// ID.
+func (c *Client) searchTweet(ctx context.Context, tweetId string) (tweet Tweet, err error) {
+	query := "conversation_id:" + tweetId
+	tweets, err := c.search(ctx, query, nil, pagination[Tweet]{limit: _pageLimit})
+	if err != nil {
+		return tweet, err
+	}
+
+	for _, t := range tweets {
+		if t.TweetId == tweetId {
+			return t, nil
+		}
+	}
+
+	return tweet, fmt.Errorf("tweet %s: %w", tweetId, ErrNotFound)
+}
+
+// GetTweetUserRetweets returns a list of users who retweeted the tweet
+func (c *Client) GetTweetUserRetweets(tweetId string) (users []User, err error) {
+	return users, ErrNotImplemented
+}
+
+type getUserFavoritesResponse struct {
+	Favoriters        []User `json:"favoriters"`
+	ContinuationToken string `json:"continuation_token"`
+}
+
+func (g *getUserFavoritesResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _favoritersKeys, &g.Favoriters, &g.ContinuationToken)
+}
+
+func (g getUserFavoritesResponse) Result() []User {
+	return g.Favoriters
+}
+
+func (g getUserFavoritesResponse) Token() string {
+	return g.ContinuationToken
+}
+
+var _ resultPaginated[User] = (*getUserFavoritesResponse)(nil)
+
+// GetTweetUserFavorites returns a list of users who favorited the tweet
+func (c *Client) GetTweetUserFavorites(tweetId string) (users []User, err error) {
+	path := []string{"tweet", "favoriters"}
+	params := []param{
+		{"tweet_id", tweetId},
+	}
+
+	return getResultPaginated[User, getUserFavoritesResponse](context.Background(), c, path, params, pagination[User]{})
+}
+
+type getSearchResponse struct {
+	Results           []Tweet `json:"results"`
+	ContinuationToken string  `json:"continuation_token"`
+
+	skipped []error
+}
 
-	if o.includeReplies {
-		params = append(params, param{"include_replies", "true"})
-	} else {
-		params = append(params, param{"include_replies", "false"})
+// UnmarshalJSON decodes the results one by one, skipping those that are
+// malformed, so that a single bad record doesn't fail the whole page.
+func (g *getSearchResponse) UnmarshalJSON(data []byte) error {
+	var results []json.RawMessage
+	err := unmarshalPage(data, _resultsKeys, &results, &g.ContinuationToken)
+	if err != nil {
+		return err
 	}
 
-	if o.includePinned {
-		params = append(params, param{"include_pinned", "true"})
-	} else {
-		params = append(params, param{"include_pinned", "false"})
+	g.Results = make([]Tweet, 0, len(results))
+	for i, raw := range results {
+		var t Tweet
+		err := json.Unmarshal(raw, &t)
+		if err != nil {
+			g.skipped = append(g.skipped, fmt.Errorf("search result %d: %w", i, err))
+			continue
+		}
+		g.Results = append(g.Results, t)
 	}
 
-	return getResultPaginated[Tweet, getUserTweetsResponse](c, path, params)
+	return nil
 }
 
-type getUserFollowsResponse struct {
-	Results           []User `json:"results"`
-	ContinuationToken string `json:"continuation_token"`
+func (g getSearchResponse) Skipped() []error {
+	return g.skipped
 }
 
-func (g getUserFollowsResponse) Result() []User {
+func (g getSearchResponse) Result() []Tweet {
 	return g.Results
 }
 
-func (g getUserFollowsResponse) Token() string {
+func (g getSearchResponse) Token() string {
 	return g.ContinuationToken
 }
 
-var _ resultPaginated[User] = (*getUserFollowsResponse)(nil)
-
-// GetUserFollowing returns a list of user's following.
-func (c *Client) GetUserFollowing(userId string) (following []User, err error) {
-	path := []string{"user", "following"}
-	params := []param{
-		{"user_id", userId},
-		{"limit", _pageLimit},
-	}
+var (
+	_ resultPaginated[Tweet] = (*getSearchResponse)(nil)
+	_ skipper                = (*getSearchResponse)(nil)
+)
 
-	return getResultPaginated[User, getUserFollowsResponse](c, path, params)
+type searchOptions struct {
+	geocode     string
+	section     SearchSection
+	language    string
+	startDate   string
+	endDate     string
+	unfoldDepth int
+	languages   languageSet
+	until       func(Tweet) bool
+	engagement  engagementFilter
 }
 
-// GetUserFollowers returns a list of user's followers.
-func (c *Client) GetUserFollowers(userId string) (followers []User, err error) {
-	path := []string{"user", "followers"}
-	params := []param{
-		{"user_id", userId},
-		{"limit", _pageLimit},
-	}
+type searchOption func(*searchOptions) error
+
+// SearchSection selects the ranking of search results.
+type SearchSection string
+
+const (
+	SearchTop    SearchSection = "top"
+	SearchLatest SearchSection = "latest"
+)
+
+// WithSection selects the section of results a search returns: the most
+// relevant tweets, the API's default, or the newest first.
+func WithSection(section SearchSection) searchOption {
+	return func(o *searchOptions) error {
+		if section != SearchTop && section != SearchLatest {
+			return fmt.Errorf("invalid section: %q", section)
+		}
 
-	return getResultPaginated[User, getUserFollowsResponse](c, path, params)
+		o.section = section
+		return nil
+	}
 }
 
-// GetUserLikes returns a list of user's likes given a user ID
-func (c *Client) GetUserLikes(userId string) (likes []Tweet, err error) {
-	return likes, ErrNotImplemented
+// WithSearchLanguage restricts a search to tweets in the language with the
+// given ISO 639-1 code, e.g. "en". The API does the filtering.
+func WithSearchLanguage(code string) searchOption {
+	return func(o *searchOptions) error {
+		if code == "" {
+			return errors.New("empty language")
+		}
+
+		o.language = code
+		return nil
+	}
 }
 
-// GetUserMedia returns a list of user's media given a user ID
-func (c *Client) GetUserMedia(userId string) (media any, err error) {
-	return media, ErrNotImplemented
+// WithSearchLanguageFilter keeps only tweets in one of the languages with
+// the given codes, like WithLanguageFilter. Unlike WithSearchLanguage, it is
+// applied client-side, after all pages have been fetched, and so doesn't
+// reduce the number of requests, but it accepts several languages.
+func WithSearchLanguageFilter(codes ...string) searchOption {
+	return func(o *searchOptions) error {
+		if len(codes) == 0 {
+			return errors.New("no languages")
+		}
+
+		o.languages = newLanguageSet(codes)
+		return nil
+	}
 }
 
-type getTweetRepliesResponse struct {
-	Replies           []Tweet `json:"replies"`
-	ContinuationToken string  `json:"continuation_token"`
+// WithSearchMinFavorites keeps only tweets liked at least n times. It is
+// applied client-side to each page as it is fetched, so it doesn't reduce
+// the number of requests, but a limit, such as SearchBuilder.Limit or
+// WithGlobalMaxResults, counts only the tweets kept.
+func WithSearchMinFavorites(n int) searchOption {
+	return func(o *searchOptions) error {
+		if n < 0 {
+			return fmt.Errorf("invalid min favorites: %d", n)
+		}
+
+		o.engagement.minFavorites = n
+		return nil
+	}
 }
 
-func (g getTweetRepliesResponse) Result() []Tweet {
-	return g.Replies
+// WithSearchMinRetweets keeps only tweets retweeted at least n times, like
+// WithSearchMinFavorites.
+func WithSearchMinRetweets(n int) searchOption {
+	return func(o *searchOptions) error {
+		if n < 0 {
+			return fmt.Errorf("invalid min retweets: %d", n)
+		}
+
+		o.engagement.minRetweets = n
+		return nil
+	}
 }
 
-func (g getTweetRepliesResponse) Token() string {
-	return g.ContinuationToken
+// WithSearchUntilSeen stops a search at the first tweet for which seen
+// returns true, e.g. one already stored, returning only the tweets before
+// it. Combined with WithSection(SearchLatest), which returns the newest
+// tweets first, this fetches just the tweets posted since the last sync.
+// Pagination stops at the first match, so tweets after it are never
+// fetched even if they haven't been seen.
+func WithSearchUntilSeen(seen func(Tweet) bool) searchOption {
+	return func(o *searchOptions) error {
+		if seen == nil {
+			return errors.New("nil seen predicate")
+		}
+
+		o.until = seen
+		return nil
+	}
 }
 
-var _ resultPaginated[Tweet] = (*getTweetRepliesResponse)(nil)
+// WithDateRange restricts a search to tweets posted from the day of start to
+// the day of end, in UTC. A zero start or end leaves that side open.
+func WithDateRange(start, end time.Time) searchOption {
+	return func(o *searchOptions) error {
+		if !start.IsZero() && !end.IsZero() && end.Before(start) {
+			return fmt.Errorf("invalid date range: %s is before %s", end, start)
+		}
 
-// GetTweetReplies returns a list of replies to a tweet.
-func (c *Client) GetTweetReplies(tweetId string) (replies []Tweet, err error) {
-	path := []string{"tweet", "replies"}
-	params := []param{
-		{"tweet_id", tweetId},
+		o.startDate, o.endDate = "", ""
+		if !start.IsZero() {
+			o.startDate = start.UTC().Format("2006-01-02")
+		}
+		if !end.IsZero() {
+			o.endDate = end.UTC().Format("2006-01-02")
+		}
+		return nil
 	}
+}
+
+// WithGeocode restricts a search to tweets posted within radius of the given
+// latitude and longitude. radius is a positive number followed by "mi" or
+// "km", e.g. "10km".
+func WithGeocode(latitude, longitude float64, radius string) searchOption {
+	return func(o *searchOptions) error {
+		if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			return fmt.Errorf("invalid coordinates: %v,%v", latitude, longitude)
+		}
+
+		unit := strings.TrimLeft(radius, "0123456789.")
+		if unit != "mi" && unit != "km" {
+			return fmt.Errorf("invalid radius unit in %q: must be mi or km", radius)
+		}
+
+		distance, err := strconv.ParseFloat(strings.TrimSuffix(radius, unit), 64)
+		if err != nil || distance <= 0 {
+			return fmt.Errorf("invalid radius: %q", radius)
+		}
 
-	return getResultPaginated[Tweet, getTweetRepliesResponse](c, path, params)
+		o.geocode = fmt.Sprintf("%s,%s,%s",
+			strconv.FormatFloat(latitude, 'f', -1, 64),
+			strconv.FormatFloat(longitude, 'f', -1, 64),
+			radius,
+		)
+		return nil
+	}
 }
 
-type getTweetDetailsResponse = Tweet
+// WithUnfoldEmbedded returns the retweeted and quoted tweets embedded in
+// search results as entries of their own, right after the tweet embedding
+// them, following embeddings up to depth levels deep. A tweet is returned at
+// most once, so a tweet that is both matched and embedded isn't counted
+// twice. A limit, such as SearchBuilder.Limit or WithGlobalMaxResults,
+// counts the unfolded entries too. By default embedded tweets are only
+// reachable through RetweetStatus and QuotedStatus.
+func WithUnfoldEmbedded(depth int) searchOption {
+	return func(o *searchOptions) error {
+		if depth < 0 {
+			return fmt.Errorf("invalid unfold depth: %d", depth)
+		}
 
-func (g getTweetDetailsResponse) Result() Tweet {
-	return g
+		o.unfoldDepth = depth
+		return nil
+	}
 }
 
-var _ result[Tweet] = (*getTweetDetailsResponse)(nil)
+func searchRequest(query string, opts []searchOption) (path []string, params []param, o searchOptions, err error) {
+	path = []string{"search", "search"}
+	params = []param{
+		{"query", query},
+		{"limit", _pageLimit},
+	}
 
-// GetTweetDetails returns general information about a tweet.
-func (c *Client) GetTweetDetails(tweetId string) (tweet Tweet, err error) {
-	path := []string{"tweet", "details"}
-	params := []param{
-		{"tweet_id", tweetId},
+	for _, opt := range opts {
+		err := opt(&o)
+		if err != nil {
+			return nil, nil, o, fmt.Errorf("bad option: %w", err)
+		}
+	}
+
+	if o.geocode != "" {
+		params = append(params, param{"geocode", o.geocode})
+	}
+	if o.section != "" {
+		params = append(params, param{"section", string(o.section)})
+	}
+	if o.language != "" {
+		params = append(params, param{"language", o.language})
+	}
+	if o.startDate != "" {
+		params = append(params, param{"start_date", o.startDate})
+	}
+	if o.endDate != "" {
+		params = append(params, param{"end_date", o.endDate})
 	}
 
-	return getResult[Tweet, getTweetDetailsResponse](c, path, params)
+	return path, params, o, nil
 }
 
-// GetTweetUserRetweets returns a list of users who retweeted the tweet
-func (c *Client) GetTweetUserRetweets(tweetId string) (users []User, err error) {
-	return users, ErrNotImplemented
+func (c *Client) search(ctx context.Context, query string, opts []searchOption, pg pagination[Tweet]) (tweets []Tweet, err error) {
+	path, params, o, err := searchRequest(query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.until != nil {
+		pg.until = o.until
+	}
+	if o.engagement.active() {
+		pg.keep = o.engagement.keep
+	}
+
+	tweets, err = getResultPaginated[Tweet, getSearchResponse](ctx, c, path, params, pg)
+	if tweets != nil {
+		tweets = o.apply(tweets)
+	}
+	// Unfolding adds entries after pagination has applied the limit.
+	if limit := c.resultLimit(pg.limit); limit > 0 && len(tweets) > limit {
+		tweets = tweets[:limit]
+	}
+
+	return tweets, err
 }
 
-type getUserFavoritesResponse struct {
-	Favoriters        []User `json:"favoriters"`
-	ContinuationToken string `json:"continuation_token"`
+// apply unfolds tweets and applies the language filter, the options that
+// act on the results once they have been fetched.
+func (o searchOptions) apply(tweets []Tweet) []Tweet {
+	if o.unfoldDepth > 0 {
+		tweets = unfoldTweets(tweets, o.unfoldDepth)
+	}
+	if o.languages != nil {
+		tweets = filter(tweets, o.languages.keep)
+	}
+	return tweets
 }
 
-func (g getUserFavoritesResponse) Result() []User {
-	return g.Favoriters
+// unfoldTweets lists each tweet followed by the tweets it retweets or quotes,
+// up to depth levels deep, skipping tweets already listed.
+func unfoldTweets(tweets []Tweet, depth int) []Tweet {
+	unfolded := make([]Tweet, 0, len(tweets))
+	seen := make(map[string]bool, len(tweets))
+
+	var add func(t Tweet, depth int)
+	add = func(t Tweet, depth int) {
+		if t.TweetId != "" {
+			if seen[t.TweetId] {
+				return
+			}
+			seen[t.TweetId] = true
+		}
+		unfolded = append(unfolded, t)
+
+		if depth == 0 {
+			return
+		}
+		if t.RetweetStatus != nil {
+			add(*t.RetweetStatus, depth-1)
+		}
+		if t.QuotedStatus != nil {
+			add(*t.QuotedStatus, depth-1)
+		}
+	}
+
+	for _, t := range tweets {
+		add(t, depth)
+	}
+	return unfolded
 }
 
-func (g getUserFavoritesResponse) Token() string {
-	return g.ContinuationToken
+// Search returns a list of tweets matching a query.
+func (c *Client) Search(query string, opts ...searchOption) (tweets []Tweet, err error) {
+	return c.search(context.Background(), query, opts, pagination[Tweet]{})
 }
 
-var _ resultPaginated[User] = (*getUserFavoritesResponse)(nil)
+// SearchEach calls fn for each tweet matching a query, fetching pages as
+// they are needed rather than collecting all results first. It accepts the
+// same options as Search, but unfolds each page's tweets on its own, so a
+// tweet embedded on several pages is passed to fn once per page. It stops
+// at the first error from fn, which it returns, unless that error is
+// ErrStop, in which case it returns nil. It also stops when ctx is done or
+// at the tweet WithSearchUntilSeen matches.
+func (c *Client) SearchEach(ctx context.Context, query string, fn func(Tweet) error, opts ...searchOption) error {
+	path, params, o, err := searchRequest(query, opts)
+	if err != nil {
+		return err
+	}
 
-// GetTweetUserFavorites returns a list of users who favorited the tweet
-func (c *Client) GetTweetUserFavorites(tweetId string) (users []User, err error) {
-	path := []string{"tweet", "favoriters"}
-	params := []param{
-		{"tweet_id", tweetId},
+	cur := newCursor[Tweet, getSearchResponse](c, path, params, func(tweets []Tweet) []Tweet {
+		if o.engagement.active() {
+			tweets = filter(tweets, o.engagement.keep)
+		}
+		return o.apply(tweets)
+	})
+	for more := true; more; {
+		var tweets []Tweet
+		tweets, more, err = cur.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tweets {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if o.until != nil && o.until(t) {
+				return nil
+			}
+
+			err := fn(t)
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	return getResultPaginated[User, getUserFavoritesResponse](c, path, params)
+	return nil
 }
 
-type getSearchResponse struct {
-	Results           []Tweet `json:"results"`
-	ContinuationToken string  `json:"continuation_token"`
-}
+// SearchCount counts the tweets matching a query, fetching pages only until
+// max tweets have been seen. The result is therefore capped at max, and is
+// only as exact as the search index the API exposes.
+func (c *Client) SearchCount(query string, max int) (count int, err error) {
+	if max <= 0 {
+		return 0, fmt.Errorf("invalid max: %d", max)
+	}
 
-// Search returns a list of tweets matching a query.
-func (c *Client) Search(query string) (tweets []Tweet, err error) {
-	return tweets, ErrNotImplemented
+	tweets, err := c.search(context.Background(), query, nil, pagination[Tweet]{limit: max})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(tweets), nil
 }
 
 type geoSearchOptions struct {
@@ -526,24 +2878,267 @@ func (c *Client) Hashtag(hashtag string) (tweets []Tweet, err error) {
 	  "description": null
 	}
 */
-type List = any
+type List struct {
+	ListId             string   `json:"list_id"`
+	ListIdStr          string   `json:"list_id_str"`
+	MemberCount        Count    `json:"member_count"`
+	Name               string   `json:"name"`
+	SubscriberCount    Count    `json:"subscriber_count"`
+	CreationDate       string   `json:"creation_date"`
+	Mode               ListMode `json:"mode"`
+	DefaultBannerMedia struct {
+		MediaInfo struct {
+			OriginalImgUrl    string `json:"original_img_url"`
+			OriginalImgWidth  int    `json:"original_img_width"`
+			OriginalImgHeight int    `json:"original_img_height"`
+		} `json:"media_info"`
+	} `json:"default_banner_media"`
+	User        User   `json:"user"`
+	Description string `json:"description"`
+}
+
+// IsPublic reports whether the list is public.
+func (l List) IsPublic() bool {
+	return l.Mode == ListModePublic
+}
+
+// ListMode is the visibility of a list. Values other than the known
+// constants are kept as sent by the API.
+type ListMode string
+
+const (
+	ListModePublic  = ListMode("Public")
+	ListModePrivate = ListMode("Private")
+)
+
+type getListDetailsResponse = List
+
+func (r getListDetailsResponse) Result() List {
+	return r
+}
 
+var _ result[List] = (*getListDetailsResponse)(nil)
+
+// GetListDetails returns general information about a list.
 func (c *Client) GetListDetails(listId string) (list List, err error) {
-	return list, ErrNotImplemented
+	return c.getListDetails(context.Background(), listId)
+}
+
+// GetListByURL returns general information about the list a twitter.com or
+// x.com list link points to, as parsed by ParseListURL.
+func (c *Client) GetListByURL(ctx context.Context, url string) (list List, err error) {
+	listId, err := ParseListURL(url)
+	if err != nil {
+		return list, err
+	}
+
+	return c.getListDetails(ctx, listId)
+}
+
+func (c *Client) getListDetails(ctx context.Context, listId string) (list List, err error) {
+	path := []string{"lists", "details"}
+	params := []param{
+		{"list_id", listId},
+	}
+
+	return getResult[List, getListDetailsResponse](ctx, c, path, params)
+}
+
+type getListTweetsResponse struct {
+	Results           []Tweet `json:"results"`
+	ContinuationToken string  `json:"continuation_token"`
+}
+
+func (g *getListTweetsResponse) UnmarshalJSON(data []byte) error {
+	return unmarshalPage(data, _resultsKeys, &g.Results, &g.ContinuationToken)
+}
+
+func (g getListTweetsResponse) Result() []Tweet {
+	return g.Results
 }
 
+func (g getListTweetsResponse) Token() string {
+	return g.ContinuationToken
+}
+
+var _ resultPaginated[Tweet] = (*getListTweetsResponse)(nil)
+
+// GetListTweets returns the tweets of a list's timeline, newest first. The
+// endpoint takes no ordering parameters and lists have no pinned tweets, so
+// this is the order the list shows on Twitter. A list without tweets yields
+// an empty slice.
 func (c *Client) GetListTweets(listId string) (tweets []Tweet, err error) {
-	return tweets, ErrNotImplemented
+	path := []string{"lists", "tweets"}
+	params := []param{
+		{"list_id", listId},
+		{"limit", _pageLimit},
+	}
+
+	return getResultPaginated[Tweet, getListTweetsResponse](context.Background(), c, path, params, pagination[Tweet]{})
+}
+
+// WOEIDs of common locations for GetTrends. Others can be looked up with
+// FindWOEID.
+const (
+	WOEIDWorldwide     = 1
+	WOEIDUnitedStates  = 23424977
+	WOEIDUnitedKingdom = 23424975
+	WOEIDCanada        = 23424775
+	WOEIDAustralia     = 23424748
+	WOEIDIndia         = 23424848
+	WOEIDJapan         = 23424856
+	WOEIDGermany       = 23424829
+	WOEIDFrance        = 23424819
+	WOEIDBrazil        = 23424768
+	WOEIDSpain         = 23424950
+	WOEIDMexico        = 23424900
+
+	WOEIDNewYork    = 2459115
+	WOEIDLosAngeles = 2442047
+	WOEIDLondon     = 44418
+	WOEIDTokyo      = 1118370
+)
+
+type Trend struct {
+	Name  string `json:"name"`
+	Url   string `json:"url"`
+	Query string `json:"query"`
+	// TweetVolume is the number of tweets in the last 24 hours, or 0 if
+	// the API doesn't say.
+	TweetVolume int64 `json:"tweet_volume"`
+}
+
+type getTrendsResponse []struct {
+	Trends []Trend `json:"trends"`
 }
 
-type Trend = any
+func (r getTrendsResponse) Result() []Trend {
+	var trends []Trend
+	for _, place := range r {
+		trends = append(trends, place.Trends...)
+	}
+	return trends
+}
 
+var _ result[[]Trend] = (*getTrendsResponse)(nil)
+
+// GetTrends returns the trends at the location with the WOEID woeId, such as
+// WOEIDWorldwide or one returned by FindWOEID.
 func (c *Client) GetTrends(woeId int) (trends []Trend, err error) {
-	return trends, ErrNotImplemented
+	if woeId <= 0 {
+		return nil, fmt.Errorf("invalid WOEID: %d", woeId)
+	}
+
+	path := []string{"trends"}
+	params := []param{
+		{"woeid", woeId},
+	}
+
+	trends, err = getResult[[]Trend, getTrendsResponse](context.Background(), c, path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if trends == nil {
+		trends = []Trend{}
+	}
+	return trends, nil
 }
 
-type Location = any
+type Location struct {
+	Name      string `json:"name"`
+	PlaceType struct {
+		Code int    `json:"code"`
+		Name string `json:"name"`
+	} `json:"placeType"`
+	Url         string `json:"url"`
+	ParentId    int    `json:"parentid"`
+	Country     string `json:"country"`
+	WoeId       int    `json:"woeid"`
+	CountryCode string `json:"countryCode"`
+}
+
+type getLocationsResponse []Location
+
+func (r getLocationsResponse) Result() []Location {
+	return r
+}
+
+var _ result[[]Location] = (*getLocationsResponse)(nil)
+
+// locationList holds the locations list once it has been fetched.
+type locationList struct {
+	mu   sync.Mutex
+	list []Location
+}
 
+// GetLocations returns the locations that have trends. The list is fetched
+// once and reused by later calls, each of which gets its own copy.
 func (c *Client) GetLocations() (locations []Location, err error) {
-	return locations, ErrNotImplemented
+	c.options.locations.mu.Lock()
+	defer c.options.locations.mu.Unlock()
+
+	if c.options.locations.list != nil {
+		return append([]Location{}, c.options.locations.list...), nil
+	}
+
+	path := []string{"trends", "available"}
+
+	locations, err = getResult[[]Location, getLocationsResponse](context.Background(), c, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if locations == nil {
+		locations = []Location{}
+	}
+
+	c.options.locations.list = append([]Location{}, locations...)
+	return locations, nil
+}
+
+// FindWOEID returns the WOEID of the location named placeName, compared
+// case-insensitively, for use with GetTrends. If no name matches exactly, a
+// single location whose name contains placeName is accepted. It fails with
+// ErrLocationNotFound if nothing matches and ErrAmbiguousLocation if several
+// locations do. An empty or blank placeName is an error, rather than a
+// match for every location.
+func (c *Client) FindWOEID(placeName string) (woeId int, err error) {
+	name := strings.ToLower(strings.TrimSpace(placeName))
+	if name == "" {
+		return 0, errors.New("empty place name")
+	}
+
+	locations, err := c.GetLocations()
+	if err != nil {
+		return 0, err
+	}
+
+	var exact, containing []Location
+	for _, l := range locations {
+		switch candidate := strings.ToLower(l.Name); {
+		case candidate == name:
+			exact = append(exact, l)
+		case strings.Contains(candidate, name):
+			containing = append(containing, l)
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = containing
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("%q: %w", placeName, ErrLocationNotFound)
+	case 1:
+		return matches[0].WoeId, nil
+	}
+
+	candidates := make([]string, len(matches))
+	for i, l := range matches {
+		candidates[i] = fmt.Sprintf("%s (%s, %d)", l.Name, l.Country, l.WoeId)
+	}
+	return 0, fmt.Errorf("%q matches %s: %w", placeName, strings.Join(candidates, ", "), ErrAmbiguousLocation)
 }
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchmarkPage returns the body of a full page of _pageLimit tweets,
+// made of the tweets of the user_tweets fixture.
+func benchmarkPage(b *testing.B) string {
+	b.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "user_tweets.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var page struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	err = json.Unmarshal(data, &page)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	results := make([]json.RawMessage, _pageLimit)
+	for i := range results {
+		results[i] = page.Results[i%len(page.Results)]
+	}
+	body, err := json.Marshal(map[string]any{
+		"results":            results,
+		"continuation_token": "DAABCgABF7MRnTr__-sKAAIXsjsrYhcQ8AgAAwAAAAIAAA",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(body)
+}
+
+// BenchmarkReadBody compares reading a full page with io.ReadAll, as
+// before readBody, with readBody, which sizes its buffer from
+// Content-Length when the response has one.
+func BenchmarkReadBody(b *testing.B) {
+	body := benchmarkPage(b)
+
+	benchmarks := []struct {
+		name          string
+		contentLength int64
+		read          func(resp *http.Response) ([]byte, error)
+	}{
+		{"ReadAll", int64(len(body)), func(resp *http.Response) ([]byte, error) { return io.ReadAll(resp.Body) }},
+		{"ContentLength", int64(len(body)), readBody},
+		{"UnknownLength", -1, readBody},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				resp := &http.Response{
+					Body:          io.NopCloser(strings.NewReader(body)),
+					ContentLength: bm.contentLength,
+				}
+				data, err := bm.read(resp)
+				if err != nil || len(data) != len(body) {
+					b.Fatalf("read %d bytes, %v", len(data), err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetUserTweetsPage measures fetching and decoding a full page
+// end to end.
+func BenchmarkGetUserTweetsPage(b *testing.B) {
+	body := benchmarkPage(b)
+	m := &mockTransport{
+		routes: []route{{path: "/user/tweets", responses: []response{{body: body}}}},
+		served: make([]int, 1),
+	}
+	c, err := New("test-key", WithHttpClient(http.Client{Transport: m}))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		tweets, _, err := c.GetUserTweetsPage("783214", "")
+		if err != nil || len(tweets) != _pageLimit {
+			b.Fatalf("got %d tweets, %v", len(tweets), err)
+		}
+		m.requests = m.requests[:0]
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	var big bytes.Buffer
+	for big.Len() <= _maxBodyPrealloc {
+		big.WriteString(strings.Repeat("x", 1<<20))
+	}
+
+	for _, body := range []string{"", "{}", big.String()} {
+		for _, contentLength := range []int64{int64(len(body)), -1} {
+			resp := &http.Response{
+				Body:          io.NopCloser(strings.NewReader(body)),
+				ContentLength: contentLength,
+			}
+			data, err := readBody(resp)
+			if err != nil || string(data) != body {
+				t.Errorf("readBody of %d bytes with Content-Length %d = %d bytes, %v", len(body), contentLength, len(data), err)
+			}
+		}
+	}
+}
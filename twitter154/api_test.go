@@ -0,0 +1,1466 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/ratelimit"
+)
+
+func TestNewOptionErrors(t *testing.T) {
+	_, err := New("key", WithMaxPages(0), WithCallTimeout(-time.Second))
+	if err == nil {
+		t.Fatal("New succeeded with invalid options")
+	}
+
+	for _, want := range []string{"invalid max pages: 0", "invalid call timeout: -1s"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(EnvAPIKey, "")
+	_, err := NewFromEnv()
+	if err == nil {
+		t.Error("NewFromEnv succeeded without an API key")
+	}
+
+	t.Setenv(EnvAPIKey, "env-key")
+	t.Setenv(EnvHost, "env.example.com")
+	c, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv: %v", err)
+	}
+	if c.apiKey != "env-key" || c.options.host != "env.example.com" {
+		t.Errorf("key, host = %q, %q", c.apiKey, c.options.host)
+	}
+
+	c, err = NewFromEnv(WithHost("option.example.com"))
+	if err != nil {
+		t.Fatalf("NewFromEnv: %v", err)
+	}
+	if c.options.host != "option.example.com" {
+		t.Errorf("host = %q, want the WithHost host", c.options.host)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	c, m := newFixtureClient(t,
+		WithAppTag("dashboard"),
+		WithAcceptLanguage("pt-BR"),
+		WithRequestID(func() string { return "req-1" }),
+	)
+
+	_, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := m.sent()[0].Header
+	want := map[string]string{
+		"X-RapidAPI-Key":  "test-key",
+		"X-RapidAPI-Host": "twitter154.p.rapidapi.com",
+		"X-App-Tag":       "dashboard",
+		"Accept-Language": "pt-BR",
+		"X-Request-ID":    "req-1",
+	}
+	for k, v := range want {
+		if got := header.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestBuildUrlWithParameters(t *testing.T) {
+	c, _ := newTestClient(t, nil)
+
+	tests := []struct {
+		params []param
+		want   string
+	}{
+		{
+			params: []param{{"query", "a b&c"}, {"limit", 100}},
+			want:   "https://twitter154.p.rapidapi.com/search/search?limit=100&query=a+b%26c",
+		},
+		{
+			// A repeated key takes the last value.
+			params: []param{{"limit", 10}, {"limit", 20}},
+			want:   "https://twitter154.p.rapidapi.com/search/search?limit=20",
+		},
+		{
+			// A []string value adds one param per element.
+			params: []param{{"id", []string{"1", "2"}}, {"id", []string{"3"}}},
+			want:   "https://twitter154.p.rapidapi.com/search/search?id=1&id=2&id=3",
+		},
+	}
+	for _, tt := range tests {
+		if got := c.buildUrlWithParameters([]string{"search", "search"}, tt.params); got != tt.want {
+			t.Errorf("buildUrlWithParameters(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultParams(t *testing.T) {
+	c, m := newFixtureClient(t, WithDefaultParams(map[string]string{
+		"limit":   "20",
+		"country": "de",
+	}))
+
+	_, err := c.GetListTweets("1591033111726391297")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := m.sent()[0].URL.Query()
+	if got := query.Get("limit"); got != "100" {
+		t.Errorf("limit = %q, want the method's 100", got)
+	}
+	if got := query.Get("country"); got != "de" {
+		t.Errorf("country = %q, want the default de", got)
+	}
+}
+
+func TestSinceMaxIdParams(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/user/tweets", []string{"3", "2"}, []string{"1"}))
+
+	_, err := c.GetUserTweets("783214", WithSinceId("1"), WithMaxId("3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := m.sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(sent))
+	}
+	for _, req := range sent {
+		query := req.URL.Query()
+		if query.Get("since_id") != "1" || query.Get("max_id") != "3" {
+			t.Errorf("%s: since_id, max_id = %q, %q", req.URL.Path, query.Get("since_id"), query.Get("max_id"))
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/username", responses: []response{{body: fixture(t, "user_username")}}},
+	})
+
+	err := c.Ping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.sent()[0].URL.Query().Get("user_id"); got != _pingUserId {
+		t.Errorf("user_id = %q, want %q", got, _pingUserId)
+	}
+
+	c, _ = newTestClient(t, []route{
+		{path: "/user/username", responses: []response{{status: http.StatusUnauthorized}}},
+	})
+	err = c.Ping(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("err = %v, want a 401 APIError", err)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/user/tweets", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}))
+
+	tweets, err := c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"5", "4", "3", "2", "1"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if n := len(m.sentTo("/user/tweets/continuation")); n != 2 {
+		t.Errorf("sent %d continuation requests, want 2", n)
+	}
+}
+
+func TestPartialResults(t *testing.T) {
+	routes := func() []route {
+		return []route{
+			{path: "/user/tweets", responses: []response{{body: tweetPage("p1", "2", "1")}}},
+			{path: "/user/tweets/continuation", responses: []response{{status: http.StatusInternalServerError}}},
+		}
+	}
+
+	c, _ := newTestClient(t, routes())
+	tweets, err := c.GetUserTweets("783214")
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("err = %v, want ErrServer", err)
+	}
+	if tweets != nil {
+		t.Errorf("tweets = %v, want nil", tweets)
+	}
+
+	c, _ = newTestClient(t, routes(), WithPartialResults())
+	tweets, err = c.GetUserTweets("783214")
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("err = %v, want ErrServer", err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"2", "1"}) {
+		t.Errorf("tweet ids = %v, want the first page", ids)
+	}
+}
+
+func TestRepeatedToken(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: tweetPage("same", "3")}}},
+		{path: "/user/tweets/continuation", responses: []response{{body: tweetPage("same", "2")}}},
+	})
+
+	_, err := c.GetUserTweets("783214")
+	if !errors.Is(err, ErrRepeatedToken) {
+		t.Errorf("err = %v, want ErrRepeatedToken", err)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestMaxPages(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/user/tweets", []string{"3"}, []string{"2"}, []string{"1"}), WithMaxPages(2))
+
+	_, err := c.GetUserTweets("783214")
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Errorf("err = %v, want ErrTooManyPages", err)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestGetUserMediaGrouped(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/medias", responses: []response{{body: fixture(t, "user_medias")}}},
+	})
+
+	photos, videos, err := c.GetUserMediaGrouped("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(photos) != 1 || photos[0].Type != "photo" {
+		t.Errorf("photos = %+v, want the one photo", photos)
+	}
+	// Animated GIFs are grouped with videos.
+	if len(videos) != 2 || videos[0].Type != "video" || videos[1].Type != "animated_gif" {
+		t.Errorf("videos = %+v, want the video and the GIF", videos)
+	}
+}
+
+func TestSinglePage(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/user/tweets", []string{"3", "2"}, []string{"1"}), WithSinglePage())
+
+	tweets, err := c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets) != 2 {
+		t.Errorf("got %d tweets, want the first page's 2", len(tweets))
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestContinuation(t *testing.T) {
+	path := []string{"user", "tweets"}
+	params := []param{{"user_id", "783214"}}
+
+	// Later pages under the continuation sub-path.
+	c, m := newTestClient(t, tweetPages("/user/tweets", []string{"3", "2"}, []string{"1"}))
+	tweets, err := getResultPaginated[Tweet, getUserTweetsResponse](context.Background(), c, path, params, pagination[Tweet]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "2", "1"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if n := len(m.sentTo("/user/tweets/continuation")); n != 1 {
+		t.Errorf("sent %d continuation requests, want 1", n)
+	}
+
+	// Later pages at the endpoint itself.
+	c, m = newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: tweetPage("p1", "3", "2")}}},
+		{path: "/user/tweets", query: map[string]string{"continuation_token": "p1"}, responses: []response{{body: tweetPage("", "1")}}},
+	})
+	tweets, err = getResultPaginated[Tweet, getUserTweetsResponse](context.Background(), c, path, params, pagination[Tweet]{continuation: continuationQuery})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "2", "1"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if sent := m.sentTo("/user/tweets"); len(sent) != 2 || sent[1].URL.Query().Get("continuation_token") != "p1" {
+		t.Errorf("sent %d requests to the endpoint, want the second with the token", len(sent))
+	}
+
+	results, next, err := getResultPage[Tweet, getUserTweetsResponse](context.Background(), c, path, params, continuationQuery, "p1", nil)
+	if err != nil || next != "" || !equalStrings(tweetIds(results), []string{"1"}) {
+		t.Errorf("getResultPage = %v, %q, %v", tweetIds(results), next, err)
+	}
+}
+
+func TestDetailsFallback(t *testing.T) {
+	routes := []route{
+		{path: "/tweet/details", responses: []response{{status: http.StatusTooManyRequests}}},
+		{path: "/search/search", responses: []response{{body: tweetPage("", "2", "1")}}},
+	}
+
+	c, _ := newTestClient(t, routes)
+	_, err := c.GetTweetDetails("1")
+	if !IsRateLimited(err) {
+		t.Errorf("err = %v, want the rate limit error without the fallback", err)
+	}
+
+	c, m := newTestClient(t, routes, WithDetailsFallback())
+	tweet, err := c.GetTweetDetails("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweet.TweetId != "1" {
+		t.Errorf("tweet = %q, want the searched tweet", tweet.TweetId)
+	}
+	if sent := m.sentTo("/search/search"); len(sent) != 1 || sent[0].URL.Query().Get("query") != "conversation_id:1" {
+		t.Errorf("sent %d searches, want one for the conversation", len(sent))
+	}
+
+	_, err = c.GetTweetDetails("3")
+	if !IsRateLimited(err) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want both the rate limit and the search errors", err)
+	}
+}
+
+func TestGlobalMaxResults(t *testing.T) {
+	routes := tweetPages("/search/search", []string{"5", "4"}, []string{"3", "2"}, []string{"1"})
+
+	c, m := newTestClient(t, routes, WithGlobalMaxResults(3))
+	tweets, err := c.Search("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"5", "4", "3"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+
+	// A method's own lower limit is kept.
+	count, err := c.SearchCount("golang", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	type call struct {
+		fetched int
+		token   string
+	}
+	var calls []call
+
+	c, _ := newTestClient(t, tweetPages("/user/tweets", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}),
+		WithProgress(func(fetched int, lastToken string) {
+			calls = append(calls, call{fetched, lastToken})
+		}),
+	)
+
+	_, err := c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []call{{2, "p1"}, {4, "p2"}, {5, ""}}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("progress calls = %v, want one per page: %v", calls, want)
+	}
+}
+
+func TestMissingContinuation(t *testing.T) {
+	ids := make([]string, _pageLimit)
+	for i := range ids {
+		ids[i] = fmt.Sprint(1000 - i)
+	}
+
+	var warnings []error
+	c, _ := newTestClient(t, tweetPages("/user/tweets", ids), WithWarningHook(func(err error) {
+		warnings = append(warnings, err)
+	}))
+
+	tweets, err := c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets) != _pageLimit {
+		t.Errorf("got %d tweets, want %d", len(tweets), _pageLimit)
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], ErrMissingContinuation) {
+		t.Errorf("warnings = %v, want ErrMissingContinuation", warnings)
+	}
+
+	// A short page without a token is the last page.
+	warnings = nil
+	c, _ = newTestClient(t, tweetPages("/user/tweets", ids[:10]), WithWarningHook(func(err error) {
+		warnings = append(warnings, err)
+	}))
+	_, err = c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+}
+
+func TestPageAliases(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"results", `{"results":[{"tweet_id":"2"}],"continuation_token":"next"}`},
+		{"data", `{"data":[{"tweet_id":"2"}],"continuationToken":"next"}`},
+		{"cursor", `{"results":[{"tweet_id":"2"}],"cursor":"next"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, []route{
+				{path: "/user/tweets", responses: []response{{body: tt.body}}},
+				{path: "/user/tweets/continuation", query: map[string]string{"continuation_token": "next"}, responses: []response{{body: tweetPage("", "1")}}},
+			})
+
+			tweets, err := c.GetUserTweets("783214")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := tweetIds(tweets); !equalStrings(ids, []string{"2", "1"}) {
+				t.Errorf("tweet ids = %v", ids)
+			}
+		})
+	}
+
+	var r getTweetRepliesResponse
+	err := r.UnmarshalJSON([]byte(`{"results":[{"tweet_id":"1"}],"cursor":"next"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Result()) != 1 || r.Token() != "next" {
+		t.Errorf("replies, token = %v, %q", r.Result(), r.Token())
+	}
+}
+
+func TestEmptyVersusNil(t *testing.T) {
+	empty := `{"results":[],"continuation_token":null}`
+	c, _ := newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: empty}}},
+		{path: "/user/followers", responses: []response{{body: empty}}},
+		{path: "/search/search", responses: []response{{body: empty}}},
+		{path: "/lists/tweets", responses: []response{{status: http.StatusInternalServerError}}},
+	})
+
+	tweets, err := c.GetUserTweets("783214")
+	if err != nil || tweets == nil || len(tweets) != 0 {
+		t.Errorf("GetUserTweets = %#v, %v, want an empty slice", tweets, err)
+	}
+	users, err := c.GetUserFollowers("783214")
+	if err != nil || users == nil || len(users) != 0 {
+		t.Errorf("GetUserFollowers = %#v, %v, want an empty slice", users, err)
+	}
+	tweets, err = c.Search("golang")
+	if err != nil || tweets == nil || len(tweets) != 0 {
+		t.Errorf("Search = %#v, %v, want an empty slice", tweets, err)
+	}
+	tweets, err = c.GetListTweets("1")
+	if err == nil || tweets != nil {
+		t.Errorf("GetListTweets = %#v, %v, want a nil slice and an error", tweets, err)
+	}
+}
+
+func TestGetListTweets(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/lists/tweets", responses: []response{{body: fixture(t, "lists_tweets")}}},
+	})
+
+	tweets, err := c.GetListTweets("1591033111726391297")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1707928410226413793"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if got := m.sent()[0].URL.Query().Get("list_id"); got != "1591033111726391297" {
+		t.Errorf("list_id = %q", got)
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: "{}", delay: time.Minute}}},
+	}, WithCallTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.GetUser("783214")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the call took %s despite the timeout", elapsed)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://twitter154.p.rapidapi.com/user/details", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.do(req)
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("err = %v, want ErrReadOnly", err)
+	}
+	if n := len(m.sent()); n != 0 {
+		t.Errorf("sent %d requests, want none", n)
+	}
+
+	c, m = newFixtureClient(t, WithAllowWrites())
+	req, err = http.NewRequest(http.MethodPost, "https://twitter154.p.rapidapi.com/user/details", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.do(req)
+	if err != nil {
+		t.Errorf("POST with WithAllowWrites: %v", err)
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestDisableRateLimit(t *testing.T) {
+	c, _ := newFixtureClient(t, WithMinInterval(time.Hour), WithDisableRateLimit())
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetUsername("783214")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	_, err := New("key", WithMinInterval(0))
+	if err == nil || !strings.Contains(err.Error(), "invalid min interval: 0s") {
+		t.Errorf("New with a zero interval: %v", err)
+	}
+
+	clock := newFakeClock()
+	start := clock.Now()
+	c, _ := newFixtureClient(t, WithClock(clock), WithMinInterval(time.Second))
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetUsername("783214")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := clock.Now().Sub(start), time.Duration(i)*time.Second; got != want {
+			t.Errorf("request %d sent at %s, want %s", i, got, want)
+		}
+	}
+
+	// The option given last wins.
+	clock = newFakeClock()
+	start = clock.Now()
+	c, _ = newFixtureClient(t, WithClock(clock), WithMinInterval(time.Second), WithRateLimit(ratelimit.NewUnlimited()))
+	for i := 0; i < 3; i++ {
+		_, err := c.GetUsername("783214")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := clock.waited(); len(got) != 0 {
+		t.Errorf("waits = %v, want none after WithRateLimit", got)
+	}
+	if got := clock.Now().Sub(start); got != 0 {
+		t.Errorf("clock advanced %s, want no waiting", got)
+	}
+}
+
+func TestClone(t *testing.T) {
+	c, m := newFixtureClient(t, WithDefaultParams(map[string]string{"country": "de"}))
+
+	clone, err := c.Clone(WithDefaultParams(map[string]string{"country": "fr"}), WithAppTag("clone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = clone.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := m.sent()
+	if got := sent[0].URL.Query().Get("country"); got != "fr" {
+		t.Errorf("clone's country = %q, want fr", got)
+	}
+	if got := sent[0].Header.Get("X-App-Tag"); got != "clone" {
+		t.Errorf("clone's app tag = %q, want clone", got)
+	}
+	if got := sent[1].URL.Query().Get("country"); got != "de" {
+		t.Errorf("original's country = %q, want de", got)
+	}
+	if got := sent[1].Header.Get("X-App-Tag"); got != "" {
+		t.Errorf("original's app tag = %q, want none", got)
+	}
+}
+
+func TestCloneTLSConfig(t *testing.T) {
+	config := &tls.Config{ServerName: "pinned.example.com"}
+
+	c, err := New("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := c.Clone(WithTLSConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport, ok := clone.options.httpClient.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig.ServerName != config.ServerName {
+		t.Errorf("clone's transport = %#v, want one with the TLS config", clone.options.httpClient.Transport)
+	}
+	if c.options.httpClient != http.DefaultClient {
+		t.Error("original's HTTP client changed")
+	}
+
+	// A client given with WithHttpClient is kept.
+	withClient, m := newTestClient(t, nil)
+	clone, err = withClient.Clone(WithTLSConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.options.httpClient.Transport != m {
+		t.Error("clone's HTTP client replaced the one given with WithHttpClient")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{host: "a.example.com", path: "/user/username", responses: []response{{err: errors.New("connection refused")}}},
+		{host: "b.example.com", path: "/user/username", responses: []response{{status: http.StatusBadGateway}}},
+		{host: "c.example.com", path: "/user/username", responses: []response{{body: fixture(t, "user_username")}}},
+	}, WithHosts("a.example.com", "b.example.com", "c.example.com"))
+
+	username, err := c.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "X" {
+		t.Errorf("username = %q, want X", username)
+	}
+
+	sent := m.sent()
+	if len(sent) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(sent))
+	}
+	for _, req := range sent {
+		if got := req.Header.Get("X-RapidAPI-Host"); got != req.URL.Host {
+			t.Errorf("X-RapidAPI-Host = %q for host %q", got, req.URL.Host)
+		}
+	}
+}
+
+func TestFailoverClientError(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{host: "a.example.com", path: "/user/username", responses: []response{{status: http.StatusNotFound}}},
+		{host: "b.example.com", path: "/user/username", responses: []response{{body: fixture(t, "user_username")}}},
+	}, WithHosts("a.example.com", "b.example.com"))
+
+	_, err := c.GetUsername("783214")
+	if !IsNotFound(err) {
+		t.Errorf("err = %v, want not found", err)
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want no failover for a 404", n)
+	}
+}
+
+func TestBackoffRetries(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/username", responses: []response{
+			{status: http.StatusTooManyRequests},
+			{status: http.StatusServiceUnavailable},
+			{body: fixture(t, "user_username")},
+		}},
+	}, WithBackoff(ConstantBackoff(time.Millisecond), 2))
+
+	_, err := c.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.sent()); n != 3 {
+		t.Errorf("sent %d requests, want 3", n)
+	}
+}
+
+func TestVerifiedOnly(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	users, err := c.GetUserFollowing("783214", WithVerifiedOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := userIds(users); !equalStrings(ids, []string{"44196397", "1516479922683199489"}) {
+		t.Errorf("user ids = %v, want the verified and blue verified users", ids)
+	}
+}
+
+func TestTimelineFilters(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"4","language":"en","favorite_count":100,"retweet_count":3,"media_url":["https://pbs.twimg.com/media/a.jpg"]},
+		{"tweet_id":"3","language":"DE","favorite_count":5,"retweet_count":50},
+		{"tweet_id":"2","language":"fr","favorite_count":500,"retweet_count":80,"video_url":[{"content_type":"video/mp4","url":"https://video.twimg.com/a.mp4"}]},
+		{"tweet_id":"1","language":"en","favorite_count":0,"retweet_count":0}
+	]}`
+
+	tests := []struct {
+		name string
+		opts []getUserTweetsOption
+		want []string
+	}{
+		{"none", nil, []string{"4", "3", "2", "1"}},
+		{"media only", []getUserTweetsOption{WithMediaOnly()}, []string{"4", "2"}},
+		{"languages", []getUserTweetsOption{WithLanguageFilter("de", "FR")}, []string{"3", "2"}},
+		{"no languages", []getUserTweetsOption{WithLanguageFilter()}, []string{"4", "3", "2", "1"}},
+		{"min favorites", []getUserTweetsOption{WithMinFavorites(100)}, []string{"4", "2"}},
+		{"min retweets", []getUserTweetsOption{WithMinRetweets(50)}, []string{"3", "2"}},
+		{"combined", []getUserTweetsOption{WithMinFavorites(100), WithMinRetweets(50), WithLanguageFilter("fr")}, []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}}}})
+
+			tweets, err := c.GetUserTweets("783214", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := tweetIds(tweets); !equalStrings(ids, tt.want) {
+				t.Errorf("tweet ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropReplies(t *testing.T) {
+	// A page of a timeline fetched without replies, on a plan that returns
+	// them anyway. 5 continues a thread whose earlier tweets are on a
+	// later page.
+	body := `{"results":[
+		{"tweet_id":"6","user":{"user_id":"783214"}},
+		{"tweet_id":"5","user":{"user_id":"783214"},"in_reply_to_status_id":"1","in_reply_to_user_id":"783214"},
+		{"tweet_id":"4","user":{"user_id":"783214"},"in_reply_to_status_id":"900","in_reply_to_user_id":"12"},
+		{"tweet_id":"3","user":{"user_id":"783214"},"in_reply_to_status_id":"2","in_reply_to_user_id":"783214"},
+		{"tweet_id":"2","user":{"user_id":"783214"}}
+	],"continuation_token":"p1"}`
+	c, _ := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}}}})
+
+	tweets, _, err := c.GetUserTweetsPage("783214", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"6", "5", "3", "2"}) {
+		t.Errorf("tweet ids = %v, want the reply to another user dropped", ids)
+	}
+
+	tweets, _, err = c.GetUserTweetsPage("783214", "", IncludeReplies())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"6", "5", "4", "3", "2"}) {
+		t.Errorf("tweet ids with replies = %v, want all", ids)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"1","timestamp":100},
+		{"tweet_id":"3","timestamp":300},
+		{"tweet_id":"2","timestamp":200}
+	]}`
+	c, m := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}}}})
+
+	tweets, err := c.GetUserTweets("783214", IncludePinned())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tweets[0].LikelyPinned || tweets[1].LikelyPinned || tweets[2].LikelyPinned {
+		t.Errorf("pinned = %v, %v, %v, want only the first", tweets[0].LikelyPinned, tweets[1].LikelyPinned, tweets[2].LikelyPinned)
+	}
+	if got := m.sent()[0].URL.Query().Get("include_pinned"); got != "true" {
+		t.Errorf("include_pinned = %q, want true", got)
+	}
+
+	// With no marker in the payload, a pin newer than the rest of the
+	// timeline can't be told apart from the newest tweet.
+	body = `{"results":[
+		{"tweet_id":"3","timestamp":300},
+		{"tweet_id":"2","timestamp":200}
+	]}`
+	c, _ = newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}}}})
+
+	tweets, err = c.GetUserTweets("783214", IncludePinned())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweets[0].LikelyPinned || tweets[1].LikelyPinned {
+		t.Errorf("a timeline in order has a likely pinned tweet")
+	}
+}
+
+func TestTreatNotFoundAsEmpty(t *testing.T) {
+	c, _ := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{status: http.StatusNotFound}}}})
+
+	tweets, err := c.GetUserTweets("783214", WithTreatNotFoundAsEmpty())
+	if err != nil || tweets == nil || len(tweets) != 0 {
+		t.Errorf("first page 404 = %v, %v, want an empty timeline", tweets, err)
+	}
+
+	// A 404 for a later page must not discard the pages before it.
+	routes := tweetPages("/user/tweets", []string{"2"}, []string{"1"})
+	routes[1].responses = []response{{status: http.StatusNotFound}}
+	c, _ = newTestClient(t, routes)
+
+	tweets, err = c.GetUserTweets("783214", WithTreatNotFoundAsEmpty())
+	if !IsNotFound(err) {
+		t.Errorf("later page 404 = %v, %v, want not found", tweets, err)
+	}
+
+	_, _, err = c.GetUserTweetsPage("783214", "p1", WithTreatNotFoundAsEmpty())
+	if !IsNotFound(err) {
+		t.Errorf("GetUserTweetsPage later page 404 = %v, want not found", err)
+	}
+}
+
+func TestDetailsFallbackConversationRoot(t *testing.T) {
+	search := `{"results":[
+		{"tweet_id":"10","conversation_id":"10"},
+		{"tweet_id":"11","conversation_id":"10","in_reply_to_status_id":"10"}
+	]}`
+	c, m := newTestClient(t, []route{
+		{path: "/tweet/details", responses: []response{{status: http.StatusTooManyRequests}}},
+		{path: "/search/search", query: map[string]string{"query": "conversation_id:10"}, responses: []response{{body: search}}},
+		{path: "/search/search", responses: []response{{body: `{"results":[]}`}}},
+	}, WithDetailsFallback())
+
+	tweet, err := c.GetTweetDetails("10")
+	if err != nil || tweet.TweetId != "10" {
+		t.Errorf("conversation root = %q, %v, want it found by the fallback", tweet.TweetId, err)
+	}
+	if got := m.sentTo("/search/search")[0].URL.Query().Get("query"); got != "conversation_id:10" {
+		t.Errorf("query = %q", got)
+	}
+
+	// The search for the conversation with a reply's ID finds nothing.
+	_, err = c.GetTweetDetails("11")
+	if !IsRateLimited(err) || !IsNotFound(err) {
+		t.Errorf("reply err = %v, want rate limited and not found", err)
+	}
+}
+
+func TestUserFollowersPageTotal(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: fixture(t, "user_details")}}},
+		{path: "/user/followers", responses: []response{{body: userPage("p1", "1", "2")}}},
+		{path: "/user/followers/continuation", responses: []response{{body: userPage("", "3")}}},
+	})
+
+	_, next, total, err := c.GetUserFollowersPage("783214", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 66947089 {
+		t.Errorf("first page total = %d, want the profile's follower count", total)
+	}
+
+	users, _, total, err := c.GetUserFollowersPage("783214", next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || !equalStrings(userIds(users), []string{"3"}) {
+		t.Errorf("later page = %v, total %d, want 0", userIds(users), total)
+	}
+	if n := len(m.sentTo("/user/details")); n != 1 {
+		t.Errorf("fetched the profile %d times, want once", n)
+	}
+}
+
+func TestUserTweetsWithProfile(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	user, _, err := c.GetUserTweetsWithProfile("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserId != "783214" {
+		t.Errorf("user id = %q", user.UserId)
+	}
+	if n := len(m.sentTo("/user/details")); n != 0 {
+		t.Errorf("sent %d profile requests, want the profile taken from a tweet", n)
+	}
+
+	c, m = newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: `{"results":[]}`}}},
+		{path: "/user/details", responses: []response{{body: fixture(t, "user_details")}}},
+	})
+	user, tweets, err := c.GetUserTweetsWithProfile("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "X" || len(tweets) != 0 {
+		t.Errorf("user, tweets = %+v, %v", user, tweets)
+	}
+	if n := len(m.sentTo("/user/details")); n != 1 {
+		t.Errorf("sent %d profile requests, want 1 for an empty timeline", n)
+	}
+}
+
+func TestReplySort(t *testing.T) {
+	tests := []struct {
+		sort ReplySort
+		want []string
+	}{
+		{ReplySortRelevance, []string{"1707913815258898618", "1707914021543526710", "1707915003455561815"}},
+		{ReplySortRecency, []string{"1707915003455561815", "1707914021543526710", "1707913815258898618"}},
+		{ReplySortLikes, []string{"1707915003455561815", "1707914021543526710", "1707913815258898618"}},
+	}
+	for _, tt := range tests {
+		c, _ := newFixtureClient(t)
+
+		replies, err := c.GetTweetReplies("1707913395413270958", WithReplySort(tt.sort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := tweetIds(replies); !equalStrings(ids, tt.want) {
+			t.Errorf("sort %d: reply ids = %v, want %v", tt.sort, ids, tt.want)
+		}
+	}
+}
+
+func TestAuthorRepliesOnly(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	replies, err := c.GetTweetReplies("1707913395413270958", WithAuthorRepliesOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(replies); !equalStrings(ids, []string{"1707914021543526710"}) {
+		t.Errorf("reply ids = %v, want the author's reply", ids)
+	}
+}
+
+func TestTweetRepliesPage(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	first, next, err := c.GetTweetRepliesPage("1707913395413270958", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || next == "" {
+		t.Fatalf("first page = %d replies, next %q", len(first), next)
+	}
+
+	second, next, err := c.GetTweetRepliesPage("1707913395413270958", next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(second); !equalStrings(ids, []string{"1707915003455561815"}) {
+		t.Errorf("second page reply ids = %v", ids)
+	}
+	if next != "" {
+		t.Errorf("next = %q after the last page", next)
+	}
+
+	sent := m.sentTo("/tweet/replies/continuation")
+	if len(sent) != 1 || sent[0].URL.Query().Get("continuation_token") == "" {
+		t.Errorf("continuation requests = %v", sent)
+	}
+}
+
+func TestQuotedTweet(t *testing.T) {
+	quoting := `{"tweet_id":"2","user":{"user_id":"1"},"quoted_status_id":"1"}`
+	c, _ := newTestClient(t, []route{
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "2"}, responses: []response{{body: quoting}}},
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "1"}, responses: []response{{body: `{"tweet_id":"1","text":"quoted"}`}}},
+	})
+
+	tweet, err := c.GetTweetDetails("2", WithQuotedTweet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweet.QuotedStatus == nil || tweet.QuotedStatus.Text != "quoted" {
+		t.Errorf("quoted status = %+v", tweet.QuotedStatus)
+	}
+
+	c, _ = newTestClient(t, []route{
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "2"}, responses: []response{{body: quoting}}},
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "1"}, responses: []response{{status: http.StatusNotFound}}},
+	})
+	tweet, err = c.GetTweetDetails("2", WithQuotedTweet())
+	if err != nil {
+		t.Fatalf("deleted quoted tweet: %v", err)
+	}
+	if tweet.QuotedStatus != nil {
+		t.Errorf("quoted status = %+v, want nil for a deleted tweet", tweet.QuotedStatus)
+	}
+}
+
+func TestMediaSelection(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	tweet, err := c.GetTweetDetails("1707913395413270958", WithMediaSelection(SelectBestVideo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweet.SelectedMediaUrl != "" {
+		t.Errorf("selected = %q for a tweet without video", tweet.SelectedMediaUrl)
+	}
+
+	tweets, err := c.GetUserTweets("783214", WithTimelineMediaSelection(SelectBestVideo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://video.twimg.com/amplify_video/1706790324883578880/vid/1280x720/b.mp4"
+	if got := tweets[2].SelectedMediaUrl; got != want {
+		t.Errorf("selected = %q, want %q", got, want)
+	}
+
+	tweets, err = c.GetUserTweets("783214", WithTimelineMediaSelection(SelectThumbnail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tweets[0].SelectedMediaUrl; got != "https://pbs.twimg.com/media/F7L8qZrWgAA2Vjy.jpg" {
+		t.Errorf("selected = %q, want the media URL", got)
+	}
+}
+
+func TestTweetAvailable(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "1"}, responses: []response{{body: `{"tweet_id":"1"}`}}},
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "2"}, responses: []response{{status: http.StatusNotFound, body: `{"message":"Tweet not found"}`}}},
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "3"}, responses: []response{{status: http.StatusInternalServerError}}},
+	})
+
+	tests := []struct {
+		tweetId   string
+		available bool
+		err       bool
+	}{
+		{"1", true, false},
+		{"2", false, false},
+		{"3", false, true},
+	}
+	for _, tt := range tests {
+		available, err := c.IsTweetAvailable(tt.tweetId)
+		if available != tt.available || (err != nil) != tt.err {
+			t.Errorf("IsTweetAvailable(%s) = %v, %v", tt.tweetId, available, err)
+		}
+	}
+}
+
+func TestResultTransform(t *testing.T) {
+	c, _ := newFixtureClient(t,
+		WithResultTransform(func(tweets []Tweet) []Tweet {
+			return filter(tweets, func(t Tweet) bool { return t.Language == "de" })
+		}),
+		WithResultTransform(func(users []User) []User {
+			for i := range users {
+				users[i].Name = "redacted"
+			}
+			return users
+		}),
+	)
+
+	tweets, err := c.Search("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1707925106155696464"}) {
+		t.Errorf("tweet ids = %v, want the German tweet", ids)
+	}
+
+	users, err := c.GetUserFollowers("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		if u.Name != "redacted" {
+			t.Errorf("user %s name = %q, want redacted", u.UserId, u.Name)
+		}
+	}
+}
+
+func TestResultTransformSeesPinned(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"1","timestamp":100},
+		{"tweet_id":"3","timestamp":300},
+		{"tweet_id":"2","timestamp":200}
+	]}`
+	var pinned []string
+	c, _ := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}, {body: body}}}},
+		WithResultTransform(func(tweets []Tweet) []Tweet {
+			for _, t := range tweets {
+				if t.LikelyPinned {
+					pinned = append(pinned, t.TweetId)
+				}
+			}
+			// Moving the pinned tweet must not make another one look pinned.
+			return append(tweets[1:], tweets[0])
+		}),
+	)
+
+	tweets, err := c.GetUserTweets("783214", IncludePinned())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "2", "1"}) || !tweets[2].LikelyPinned || tweets[0].LikelyPinned {
+		t.Errorf("tweets = %+v, want the transform's order with only tweet 1 pinned", tweets)
+	}
+
+	if _, _, err := c.GetUserTweetsPage("783214", "", IncludePinned()); err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(pinned, []string{"1", "1"}) {
+		t.Errorf("transform saw pinned tweets %v, want tweet 1 on each call", pinned)
+	}
+}
+
+func FuzzBuildUrlWithParameters(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"query", "golang"},
+		{"query", "héllo wörld"},
+		{"query", "日本語のツイート"},
+		{"query", "👍🏽 \U0001f468\u200d\U0001f469\u200d\U0001f467"},
+		{"qüery", "\u200d\ufeff"},
+		{"query", "a&b=c"},
+		{"a&b=c", "d"},
+		{"query", "#hashtag ?q=1 /path"},
+		{"query", "100% +plus ;semi ,comma"},
+		{"query", "%2F%zz%"},
+		{"query", "line\nbreak\ttab"},
+		{"query", ""},
+		{"[key]", "<value>"},
+	} {
+		f.Add(seed[0], seed[1])
+	}
+
+	c := &Client{options: &options{host: "twitter154.p.rapidapi.com"}}
+	f.Fuzz(func(t *testing.T, key, value string) {
+		if key == "" {
+			t.Skip()
+		}
+
+		raw := c.buildUrlWithParameters([]string{"search", "search"}, []param{{key, value}})
+
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", raw, err)
+		}
+		if u.Host != "twitter154.p.rapidapi.com" || u.Path != "/search/search" {
+			t.Errorf("host, path = %q, %q", u.Host, u.Path)
+		}
+
+		query, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			t.Fatalf("url.ParseQuery(%q): %v", u.RawQuery, err)
+		}
+		if len(query) != 1 || len(query[key]) != 1 || query[key][0] != value {
+			t.Errorf("query of %q = %q, want %q=%q", raw, query, key, value)
+		}
+	})
+}
+
+func TestLocations(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	locations, err := c.GetLocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations[0].Name = "changed"
+
+	locations, err = c.GetLocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locations[0].Name != "Worldwide" {
+		t.Errorf("first location = %q, want the cached list unchanged", locations[0].Name)
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+
+	tests := []struct {
+		name    string
+		woeId   int
+		err     error
+		invalid bool
+	}{
+		{name: "London", woeId: 44418},
+		{name: " new york ", woeId: 2459115},
+		{name: "york", woeId: 2459115},
+		{name: "Birmingham", err: ErrAmbiguousLocation},
+		{name: "Paris", err: ErrLocationNotFound},
+		{name: "", invalid: true},
+		{name: " \t", invalid: true},
+	}
+	for _, tt := range tests {
+		woeId, err := c.FindWOEID(tt.name)
+		if tt.invalid {
+			if err == nil || errors.Is(err, ErrLocationNotFound) || errors.Is(err, ErrAmbiguousLocation) {
+				t.Errorf("FindWOEID(%q) = %d, %v, want an invalid name error", tt.name, woeId, err)
+			}
+			continue
+		}
+		if woeId != tt.woeId || !errors.Is(err, tt.err) {
+			t.Errorf("FindWOEID(%q) = %d, %v, want %d, %v", tt.name, woeId, err, tt.woeId, tt.err)
+		}
+	}
+
+	_, err = c.FindWOEID("Birmingham")
+	for _, want := range []string{"12723", "2364559"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't list candidate %s", err, want)
+		}
+	}
+}
+
+func TestGetTrends(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	for _, woeId := range []int{WOEIDWorldwide, WOEIDUnitedKingdom, WOEIDTokyo} {
+		trends, err := c.GetTrends(woeId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trends) != 2 {
+			t.Errorf("GetTrends(%d) = %+v", woeId, trends)
+		}
+	}
+	for i, want := range []string{"1", "23424975", "1118370"} {
+		if got := m.sent()[i].URL.Query().Get("woeid"); got != want {
+			t.Errorf("request %d woeid = %q, want %q", i, got, want)
+		}
+	}
+
+	for _, woeId := range []int{0, -1} {
+		if _, err := c.GetTrends(woeId); err == nil {
+			t.Errorf("GetTrends(%d) succeeded", woeId)
+		}
+	}
+	if n := len(m.sent()); n != 3 {
+		t.Errorf("sent %d requests, want none for invalid WOEIDs", n)
+	}
+}
+
+func TestRandomizedRateLimit(t *testing.T) {
+	const requests = 20
+
+	clock := newFakeClock()
+	start := clock.Now()
+	c, _ := newFixtureClient(t,
+		WithClock(clock),
+		WithRandSource(rand.NewSource(1)),
+		WithMinInterval(time.Second),
+		WithRandomizedRateLimit(100*time.Millisecond),
+	)
+
+	var sentAt []time.Duration
+	for i := 0; i < requests; i++ {
+		_, err := c.GetUsername("783214")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sentAt = append(sentAt, clock.Now().Sub(start))
+	}
+
+	// Request i is sent at the limiter's i seconds plus a jitter of under
+	// 100ms, so the requests aren't perfectly periodic.
+	jitters := make(map[time.Duration]bool)
+	for i, at := range sentAt {
+		jitter := at - time.Duration(i)*time.Second
+		if jitter < 0 || jitter >= 100*time.Millisecond {
+			t.Errorf("request %d sent at %s, want a jitter in [0, 100ms)", i, at)
+		}
+		jitters[jitter] = true
+	}
+	if len(jitters) < requests/2 {
+		t.Errorf("%d distinct jitters for %d requests, want them spread out", len(jitters), requests)
+	}
+}
+
+func TestGetUsersByUsername(t *testing.T) {
+	user := func(id, username string) route {
+		return route{
+			path:      "/user/details",
+			query:     map[string]string{"username": username},
+			responses: []response{{body: fmt.Sprintf(`{"user_id":%q,"username":%q}`, id, username), delay: 10 * time.Millisecond}},
+		}
+	}
+	c, m := newTestClient(t, []route{
+		user("1", "a"),
+		user("2", "b"),
+		user("3", "c"),
+		user("4", "d"),
+		{path: "/user/details", responses: []response{{status: http.StatusNotFound, delay: 10 * time.Millisecond}}},
+	}, WithMaxConcurrency(2))
+
+	users, errs := c.GetUsersByUsername([]string{"a", "@b", "missing", " c", "d"})
+
+	if ids := userIds(users); !equalStrings(ids, []string{"1", "2", "", "3", "4"}) {
+		t.Errorf("user ids = %v", ids)
+	}
+	if len(errs) != 5 {
+		t.Fatalf("got %d errors, want one per username", len(errs))
+	}
+	for i, err := range errs {
+		if i == 2 {
+			if !IsNotFound(err) {
+				t.Errorf("errs[2] = %v, want not found", err)
+			}
+		} else if err != nil {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+
+	if n := len(m.sent()); n != 5 {
+		t.Errorf("sent %d requests, want 5", n)
+	}
+	if m.maxInFlight != 2 {
+		t.Errorf("%d requests in flight at once, want WithMaxConcurrency's 2", m.maxInFlight)
+	}
+}
+
+func TestGetUserTweetsParallelWindows(t *testing.T) {
+	window := func(maxId, token string, ids ...string) route {
+		return route{
+			path:      "/user/tweets",
+			query:     map[string]string{"max_id": maxId},
+			responses: []response{{body: tweetPage(token, ids...), delay: 10 * time.Millisecond}},
+		}
+	}
+	c, m := newTestClient(t, []route{
+		window("1600", "a", "1600", "1550"),
+		{path: "/user/tweets/continuation", query: map[string]string{"continuation_token": "a"}, responses: []response{{body: tweetPage("", "1450")}}},
+		window("1400", "", "1350"),
+		window("1200", "", "1150", "1100"),
+	}, WithMaxConcurrency(2))
+
+	tweets, err := c.GetUserTweets("783214", WithSinceId("1000"), WithMaxId("1600"), WithParallelWindows(3), IncludePinned())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1600", "1550", "1450", "1350", "1150", "1100"}) {
+		t.Errorf("tweet ids = %v, want the windows joined newest first", ids)
+	}
+
+	windows := map[string]string{"1600": "1400", "1400": "1200", "1200": "1000"}
+	for _, req := range m.sentTo("/user/tweets") {
+		query := req.URL.Query()
+		if since := query.Get("since_id"); since != windows[query.Get("max_id")] {
+			t.Errorf("window up to %s has since_id %s", query.Get("max_id"), since)
+		}
+		if pinned := query.Get("include_pinned") == "true"; pinned != (query.Get("max_id") == "1600") {
+			t.Errorf("window up to %s has include_pinned %v", query.Get("max_id"), pinned)
+		}
+	}
+	if m.maxInFlight != 2 {
+		t.Errorf("%d requests in flight at once, want WithMaxConcurrency's 2", m.maxInFlight)
+	}
+
+	for _, opts := range [][]getUserTweetsOption{
+		{WithParallelWindows(2)},
+		{WithSinceId("1000"), WithParallelWindows(2)},
+		{WithSinceId("1600"), WithMaxId("1000"), WithParallelWindows(2)},
+		{WithSinceId("1000"), WithMaxId("1600"), WithParallelWindows(-1)},
+	} {
+		if _, err := c.GetUserTweets("783214", opts...); err == nil {
+			t.Error("invalid windows accepted")
+		}
+	}
+	if n := len(m.sent()); n != 4 {
+		t.Errorf("sent %d requests, want none for invalid windows", n)
+	}
+}
+
+func TestGetTweetWithParent(t *testing.T) {
+	tweet := func(id, body string) route {
+		return route{path: "/tweet/details", query: map[string]string{"tweet_id": id}, responses: []response{{body: body}}}
+	}
+	c, m := newTestClient(t, []route{
+		tweet("9", `{"tweet_id":"9"}`),
+		tweet("10", `{"tweet_id":"10","in_reply_to_status_id":"9"}`),
+		tweet("11", `{"tweet_id":"11","in_reply_to_status_id":"8"}`),
+		tweet("12", `{"tweet_id":"12"}`),
+		tweet("13", `{"tweet_id":"13","in_reply_to_status_id":"7"}`),
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "8"}, responses: []response{{status: http.StatusNotFound}}},
+		{path: "/tweet/details", query: map[string]string{"tweet_id": "7"}, responses: []response{{status: http.StatusInternalServerError}}},
+	})
+
+	tests := []struct {
+		id     string
+		parent string
+		err    error
+	}{
+		{id: "10", parent: "9"},
+		// The parent was deleted.
+		{id: "11"},
+		// Not a reply.
+		{id: "12"},
+		{id: "13", err: ErrServer},
+	}
+	for _, tt := range tests {
+		tweet, parent, err := c.GetTweetWithParent(tt.id)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("GetTweetWithParent(%s) err = %v, want %v", tt.id, err, tt.err)
+			continue
+		}
+		if tweet.TweetId != tt.id {
+			t.Errorf("GetTweetWithParent(%s) tweet = %q", tt.id, tweet.TweetId)
+		}
+
+		var got string
+		if parent != nil {
+			got = parent.TweetId
+		}
+		if got != tt.parent || (tt.parent == "" && parent != nil) {
+			t.Errorf("GetTweetWithParent(%s) parent = %q, want %q", tt.id, got, tt.parent)
+		}
+	}
+
+	if n := len(m.sent()); n != 7 {
+		t.Errorf("sent %d requests, want 7, with none for the non-reply's parent", n)
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy decides how long to wait before retrying a failed
+// request. attempt is 1 for the first retry.
+type BackoffStrategy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// ConstantBackoff waits the same duration before every retry.
+type ConstantBackoff time.Duration
+
+func (b ConstantBackoff) NextDelay(attempt int) time.Duration {
+	return time.Duration(b)
+}
+
+// LinearBackoff waits Step times the attempt number, up to Max if it is
+// positive.
+type LinearBackoff struct {
+	Step time.Duration
+	Max  time.Duration
+}
+
+func (b LinearBackoff) NextDelay(attempt int) time.Duration {
+	d := b.Step * time.Duration(attempt)
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// ExponentialBackoff waits a random duration between zero and Base doubled
+// for each attempt after the first, capped at Max if it is positive.
+// Randomizing the whole delay spreads out clients that failed together.
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+	// Rand returns a random number in [0, n), as rand.Int63n does, which
+	// it defaults to. Setting it makes the delays reproducible.
+	Rand func(n int64) int64
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt && d > 0; i++ {
+		if b.Max > 0 && d >= b.Max {
+			break
+		}
+		if d > math.MaxInt64/2 {
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	random := b.Rand
+	if random == nil {
+		random = rand.Int63n
+	}
+	return time.Duration(random(int64(d)))
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// delays returns the delays b waits before the first n retries.
+func delays(b BackoffStrategy, n int) []time.Duration {
+	d := make([]time.Duration, n)
+	for i := range d {
+		d[i] = b.NextDelay(i + 1)
+	}
+	return d
+}
+
+func TestBackoffDelays(t *testing.T) {
+	ms := time.Millisecond
+
+	if got, want := delays(ConstantBackoff(100*ms), 3), []time.Duration{100 * ms, 100 * ms, 100 * ms}; !equalDurations(got, want) {
+		t.Errorf("constant = %v, want %v", got, want)
+	}
+
+	if got, want := delays(LinearBackoff{Step: 100 * ms, Max: 250 * ms}, 4), []time.Duration{100 * ms, 200 * ms, 250 * ms, 250 * ms}; !equalDurations(got, want) {
+		t.Errorf("linear = %v, want %v", got, want)
+	}
+
+	// Rand records the bound of each delay and picks half of it.
+	var bounds []time.Duration
+	exponential := ExponentialBackoff{
+		Base: 100 * ms,
+		Max:  time.Second,
+		Rand: func(n int64) int64 {
+			bounds = append(bounds, time.Duration(n))
+			return n / 2
+		},
+	}
+	got := delays(exponential, 6)
+	wantBounds := []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second, time.Second}
+	if !equalDurations(bounds, wantBounds) {
+		t.Errorf("exponential bounds = %v, want %v", bounds, wantBounds)
+	}
+	if want := []time.Duration{50 * ms, 100 * ms, 200 * ms, 400 * ms, 500 * ms, 500 * ms}; !equalDurations(got, want) {
+		t.Errorf("exponential = %v, want %v", got, want)
+	}
+
+	// Without Rand, delays are random but within their bounds.
+	exponential.Rand = nil
+	for i, d := range delays(exponential, 6) {
+		if d < 0 || d >= wantBounds[i] {
+			t.Errorf("exponential delay %d = %s, want in [0, %s)", i+1, d, wantBounds[i])
+		}
+	}
+}
+
+func TestBackoffClock(t *testing.T) {
+	clock := newFakeClock()
+	c, m := newTestClient(t, []route{
+		{path: "/user/username", responses: []response{
+			{status: http.StatusTooManyRequests},
+			{status: http.StatusServiceUnavailable},
+			{status: http.StatusServiceUnavailable},
+			{body: fixture(t, "user_username")},
+		}},
+	}, WithClock(clock), WithBackoff(LinearBackoff{Step: time.Minute}, 3))
+
+	_, err := c.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.sent()); n != 4 {
+		t.Errorf("sent %d requests, want 4", n)
+	}
+	if got, want := clock.waited(), []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}; !equalDurations(got, want) {
+		t.Errorf("waits = %v, want %v", got, want)
+	}
+}
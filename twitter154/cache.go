@@ -0,0 +1,192 @@
+package api
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// cache is a concurrency-safe in-memory store whose entries expire after
+// ttl. A zero ttl keeps entries forever. The time is passed in by the
+// client, from its Clock, since clients cloned with another Clock share the
+// cache.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *cache) get(key string, now time.Time) (value any, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !e.expires.IsZero() && now.After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+func (c *cache) set(key string, value any, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := cacheEntry{value: value}
+	if c.ttl > 0 {
+		e.expires = now.Add(c.ttl)
+	}
+	c.entries[key] = e
+}
+
+func userCacheKey(userId string) string {
+	return "user:" + userId
+}
+
+// usernameCacheKey is case-insensitive, as usernames are.
+func usernameCacheKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func (c *Client) cachedUser(userId string) (user User, ok bool) {
+	return c.cachedUserByKey(userCacheKey(userId))
+}
+
+func (c *Client) cachedUserByUsername(username string) (user User, ok bool) {
+	return c.cachedUserByKey(usernameCacheKey(username))
+}
+
+func (c *Client) cachedUserByKey(key string) (user User, ok bool) {
+	if c.options.cache == nil {
+		return user, false
+	}
+
+	v, ok := c.options.cache.get(key, c.options.clock.Now())
+	if !ok {
+		return user, false
+	}
+	return v.(User), true
+}
+
+// cacheUser caches user under both its ID and its username, so a lookup by
+// either is served from the cache.
+func (c *Client) cacheUser(user User) {
+	if c.options.cache == nil || user.UserId == "" {
+		return
+	}
+
+	now := c.options.clock.Now()
+	c.options.cache.set(userCacheKey(user.UserId), user, now)
+	if user.Username != "" {
+		c.options.cache.set(usernameCacheKey(user.Username), user, now)
+	}
+}
+
+func tweetCacheKey(tweetId string) string {
+	return "tweet:" + tweetId
+}
+
+// cachedNotFound returns the error a lookup of the tweet failed with if the
+// negative cache remembers that it wasn't found, and nil otherwise.
+func (c *Client) cachedNotFound(tweetId string) error {
+	if c.options.notFoundCache == nil {
+		return nil
+	}
+
+	v, ok := c.options.notFoundCache.get(tweetCacheKey(tweetId), c.options.clock.Now())
+	if !ok {
+		return nil
+	}
+	return v.(error)
+}
+
+func (c *Client) cacheNotFound(tweetId string, err error) {
+	if c.options.notFoundCache == nil {
+		return
+	}
+
+	c.options.notFoundCache.set(tweetCacheKey(tweetId), err, c.options.clock.Now())
+}
+
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// _maxHTTPCacheEntries bounds the number of URLs an httpCache remembers.
+const _maxHTTPCacheEntries = 1024
+
+// httpCache remembers the validators and body of GET responses by URL, so
+// repeated requests can be made conditional. It holds at most maxEntries
+// responses, evicting the least recently used.
+type httpCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	// lru orders the cached URLs, most recently used first; its elements
+	// hold *httpCacheEntry values.
+	lru       *list.List
+	responses map[string]*list.Element
+}
+
+type httpCacheEntry struct {
+	url      string
+	response validatedResponse
+}
+
+func newHTTPCache(maxEntries int) *httpCache {
+	return &httpCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		responses:  make(map[string]*list.Element),
+	}
+}
+
+func (h *httpCache) get(url string) (r validatedResponse, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e, ok := h.responses[url]
+	if !ok {
+		return r, false
+	}
+
+	h.lru.MoveToFront(e)
+	return e.Value.(*httpCacheEntry).response, true
+}
+
+func (h *httpCache) set(url string, r validatedResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e, ok := h.responses[url]; ok {
+		e.Value.(*httpCacheEntry).response = r
+		h.lru.MoveToFront(e)
+		return
+	}
+
+	h.responses[url] = h.lru.PushFront(&httpCacheEntry{url: url, response: r})
+	if h.lru.Len() > h.maxEntries {
+		oldest := h.lru.Back()
+		h.lru.Remove(oldest)
+		delete(h.responses, oldest.Value.(*httpCacheEntry).url)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheAuthor(t *testing.T) {
+	c, m := newFixtureClient(t, WithCache(0))
+
+	tweet, err := c.GetTweetDetails("1707913395413270958")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := c.GetUser(tweet.User.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "X" {
+		t.Errorf("cached user = %+v", user)
+	}
+	if n := len(m.sentTo("/user/details")); n != 0 {
+		t.Errorf("sent %d user requests, want the author served from the cache", n)
+	}
+}
+
+func TestCacheByIdAndUsername(t *testing.T) {
+	c, m := newFixtureClient(t, WithCache(time.Hour))
+
+	_, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetUserByUsername("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := newCache(time.Minute)
+	c.set("k", 1, now)
+
+	if _, ok := c.get("k", now.Add(time.Minute)); !ok {
+		t.Error("entry expired before its ttl")
+	}
+	if v, ok := c.get("k", now.Add(time.Minute+time.Nanosecond)); ok {
+		t.Errorf("expired entry = %v", v)
+	}
+}
+
+func TestCacheClock(t *testing.T) {
+	clock := newFakeClock()
+	c, m := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: fixture(t, "user_details")}}},
+		{path: "/tweet/details", responses: []response{{status: http.StatusNotFound}}},
+	}, WithCache(time.Hour), WithNegativeCache(time.Hour), WithClock(clock))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetUser("783214"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetTweetDetails("1"); !IsNotFound(err) {
+			t.Fatalf("err = %v, want not found", err)
+		}
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Fatalf("sent %d requests, want the second calls served from the caches", n)
+	}
+
+	// The entries expire by the client's clock, not the wall clock.
+	clock.Sleep(time.Hour + time.Second)
+	if _, err := c.GetUser("783214"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetTweetDetails("1"); !IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	if n := len(m.sent()); n != 4 {
+		t.Errorf("sent %d requests, want the expired entries fetched again", n)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/tweet/details", responses: []response{{status: http.StatusNotFound, body: `{"message":"Tweet not found"}`}}},
+	}, WithNegativeCache(0))
+
+	_, err := c.GetTweetDetails("1")
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+
+	_, err = c.GetTweetDetails("1")
+	if !IsNotFound(err) {
+		t.Errorf("second err = %v, want not found", err)
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want the second call served from the negative cache", n)
+	}
+
+	available, err := c.IsTweetAvailable("1")
+	if available || err != nil {
+		t.Errorf("IsTweetAvailable = %v, %v", available, err)
+	}
+}
+
+func TestNegativeCacheOnlyNotFound(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/tweet/details", responses: []response{{status: http.StatusInternalServerError}}},
+	}, WithNegativeCache(0))
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetTweetDetails("1")
+		if !errors.Is(err, ErrServer) {
+			t.Errorf("err = %v, want ErrServer", err)
+		}
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want server errors not cached", n)
+	}
+}
+
+func TestHTTPCacheNotModified(t *testing.T) {
+	body := fixture(t, "user_details")
+	c, m := newTestClient(t, []route{{path: "/user/details", responses: []response{
+		{header: map[string]string{"ETag": `"v1"`, "Last-Modified": "Mon, 02 Oct 2023 10:00:00 GMT"}, body: body},
+		{status: http.StatusNotModified},
+	}}}, WithBasicHTTPCache())
+
+	first, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.UserId != first.UserId || second.Username != first.Username {
+		t.Errorf("304 served %+v, want the remembered %+v", second, first)
+	}
+
+	sent := m.sent()
+	if got := sent[0].Header.Get("If-None-Match"); got != "" {
+		t.Errorf("first request If-None-Match = %q, want none", got)
+	}
+	if got := sent[1].Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("second request If-None-Match = %q, want the ETag", got)
+	}
+	if got := sent[1].Header.Get("If-Modified-Since"); got != "Mon, 02 Oct 2023 10:00:00 GMT" {
+		t.Errorf("second request If-Modified-Since = %q, want the Last-Modified", got)
+	}
+}
+
+func TestHTTPCacheFailover(t *testing.T) {
+	body := fixture(t, "user_details")
+	c, m := newTestClient(t, []route{
+		{host: "a.example.com", path: "/user/details", responses: []response{
+			{header: map[string]string{"ETag": `"a1"`}, body: body},
+			{status: http.StatusServiceUnavailable},
+		}},
+		{host: "b.example.com", path: "/user/details", responses: []response{{body: body}}},
+	}, WithHosts("a.example.com", "b.example.com"), WithBasicHTTPCache())
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetUser("783214")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent := m.sent()
+	if len(sent) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(sent))
+	}
+	if got := sent[1].Header.Get("If-None-Match"); got != `"a1"` {
+		t.Errorf("a's If-None-Match = %q, want a's ETag", got)
+	}
+	if got := sent[2].Header.Get("If-None-Match"); got != "" {
+		t.Errorf("b's If-None-Match = %q, want none, as b's URL has no validators", got)
+	}
+}
+
+func TestHTTPCacheEviction(t *testing.T) {
+	h := newHTTPCache(2)
+	h.set("a", validatedResponse{etag: "a"})
+	h.set("b", validatedResponse{etag: "b"})
+	h.get("a")
+	h.set("c", validatedResponse{etag: "c"})
+
+	if _, ok := h.get("b"); ok {
+		t.Error("least recently used entry b was kept")
+	}
+	for _, url := range []string{"a", "c"} {
+		if r, ok := h.get(url); !ok || r.etag != url {
+			t.Errorf("get(%q) = %+v, %t", url, r, ok)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock tells the time and waits for the client wherever it keeps time
+// itself: in the WithMinInterval rate limiter, for the
+// WithRandomizedRateLimit delays, between WithBackoff retries and for the
+// expiry of WithCache and WithNegativeCache entries. It is
+// satisfied by the clocks of github.com/andres-erbsen/clock, mock clocks
+// included, and is a ratelimit.Clock.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock makes the client tell the time and wait with clock rather than
+// the time package, e.g. to test code that uses WithMinInterval without
+// waiting.
+func WithClock(clock Clock) option {
+	return func(option *options) error {
+		if clock == nil {
+			return errors.New("nil clock")
+		}
+
+		option.clock = clock
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultCSVColumns are the columns written by ExportTweetsCSV when no
+// columns are requested.
+var DefaultCSVColumns = []string{
+	"id",
+	"created",
+	"username",
+	"text",
+	"favorite_count",
+	"retweet_count",
+	"reply_count",
+	"quote_count",
+}
+
+var _csvColumns = map[string]func(t Tweet) string{
+	"id":                    func(t Tweet) string { return t.TweetId },
+	"created":               func(t Tweet) string { return t.CreationDate },
+	"timestamp":             func(t Tweet) string { return strconv.FormatInt(t.Timestamp, 10) },
+	"text":                  func(t Tweet) string { return t.Text },
+	"full_text":             func(t Tweet) string { return t.FullText() },
+	"language":              func(t Tweet) string { return t.Language },
+	"user_id":               func(t Tweet) string { return t.User.UserId },
+	"username":              func(t Tweet) string { return t.User.Username },
+	"favorite_count":        func(t Tweet) string { return strconv.FormatInt(int64(t.FavoriteCount), 10) },
+	"retweet_count":         func(t Tweet) string { return strconv.FormatInt(int64(t.RetweetCount), 10) },
+	"reply_count":           func(t Tweet) string { return strconv.FormatInt(int64(t.ReplyCount), 10) },
+	"quote_count":           func(t Tweet) string { return strconv.FormatInt(int64(t.QuoteCount), 10) },
+	"views":                 func(t Tweet) string { return strconv.FormatInt(int64(t.Views), 10) },
+	"source":                func(t Tweet) string { return t.SourceName() },
+	"possibly_sensitive":    func(t Tweet) string { return strconv.FormatBool(t.IsSensitive()) },
+	"retweet":               func(t Tweet) string { return strconv.FormatBool(t.Retweet) },
+	"conversation_id":       func(t Tweet) string { return t.ConversationId },
+	"in_reply_to_status_id": func(t Tweet) string { return t.InReplyToStatusId },
+	"quoted_status_id":      func(t Tweet) string { return t.QuotedStatusId },
+	"expanded_url":          func(t Tweet) string { return t.ExpandedUrl },
+}
+
+// ExportTweetsCSV writes tweets to w as CSV, one row per tweet, preceded by a
+// header row. If columns is empty, DefaultCSVColumns is used. An unknown
+// column name is an error and nothing is written.
+func ExportTweetsCSV(tweets []Tweet, w io.Writer, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	fields := make([]func(t Tweet) string, len(columns))
+	for i, column := range columns {
+		field, ok := _csvColumns[column]
+		if !ok {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		fields[i] = field
+	}
+
+	cw := csv.NewWriter(w)
+	err := cw.Write(columns)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	record := make([]string, len(fields))
+	for _, t := range tweets {
+		for i, field := range fields {
+			record[i] = field(t)
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return fmt.Errorf("write tweet %s: %w", t.TweetId, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestExportTweetsCSV(t *testing.T) {
+	tweets := []Tweet{
+		{
+			TweetId:       "2",
+			CreationDate:  "Fri Sep 29 17:33:05 +0000 2023",
+			Text:          "commas, \"quotes\"\nand newlines",
+			User:          User{Username: "X"},
+			FavoriteCount: 10,
+		},
+		{
+			TweetId: "1",
+			Text:    "plain",
+			User:    User{Username: "jack"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := ExportTweetsCSV(tweets, &buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 rows", len(records))
+	}
+	if !equalStrings(records[0], DefaultCSVColumns) {
+		t.Errorf("header = %v", records[0])
+	}
+	if want := []string{"2", "Fri Sep 29 17:33:05 +0000 2023", "X", "commas, \"quotes\"\nand newlines", "10", "0", "0", "0"}; !equalStrings(records[1], want) {
+		t.Errorf("row = %q, want %q", records[1], want)
+	}
+
+	buf.Reset()
+	err = ExportTweetsCSV(tweets, &buf, []string{"id", "username"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "id,username\n2,X\n1,jack\n" {
+		t.Errorf("csv = %q", got)
+	}
+}
+
+func TestExportTweetsCSVUnknownColumn(t *testing.T) {
+	var buf bytes.Buffer
+	err := ExportTweetsCSV([]Tweet{{TweetId: "1"}}, &buf, []string{"id", "nope"})
+	if err == nil {
+		t.Error("unknown column accepted")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q despite the error", buf.String())
+	}
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Cursor walks a paginated endpoint one page at a time. It is not safe for
+// concurrent use.
+type Cursor[T any] struct {
+	c      *Client
+	path   []string
+	params []param
+	cont   continuation
+	page   func(ctx context.Context, c *Client, path []string, params []param, cont continuation, token string, mark func([]T)) ([]T, string, error)
+	filter func([]T) []T
+
+	token string
+	done  bool
+	// seen holds the tokens the cursor has advanced to, to catch an
+	// endpoint that cycles through several.
+	seen map[string]bool
+}
+
+// newCursor returns a cursor over an endpoint whose later pages are under
+// its "continuation" sub-path.
+func newCursor[T any, R resultPaginated[T]](c *Client, path []string, params []param, filter func([]T) []T) *Cursor[T] {
+	return &Cursor[T]{
+		c:      c,
+		path:   path,
+		params: params,
+		page:   getResultPage[T, R],
+		filter: filter,
+	}
+}
+
+// Next fetches the next page. more reports whether further pages may
+// follow; once it is false, Next returns no results. If Next fails, the
+// cursor doesn't advance and the call can be retried.
+func (cur *Cursor[T]) Next(ctx context.Context) (results []T, more bool, err error) {
+	if cur.done {
+		return []T{}, false, nil
+	}
+
+	results, next, err := cur.page(ctx, cur.c, cur.path, cur.params, cur.cont, cur.token, nil)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if cur.seen == nil {
+		cur.seen = map[string]bool{}
+	}
+	if cur.token != "" {
+		cur.seen[cur.token] = true
+	}
+	if next != "" && cur.seen[next] {
+		return nil, false, fmt.Errorf("continuation token %q: %w", next, ErrRepeatedToken)
+	}
+
+	if cur.filter != nil {
+		results = cur.filter(results)
+	}
+
+	cur.token = next
+	cur.done = next == ""
+	return results, !cur.done, nil
+}
+
+// _cursorStateVersion is the version of the format written by MarshalState.
+const _cursorStateVersion = 1
+
+type cursorState struct {
+	Version int          `json:"version"`
+	Path    []string     `json:"path"`
+	Params  []stateParam `json:"params"`
+	Token   string       `json:"token"`
+	Done    bool         `json:"done"`
+}
+
+type stateParam struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+func (cur *Cursor[T]) state() cursorState {
+	params := make([]stateParam, len(cur.params))
+	for i, p := range cur.params {
+		params[i] = stateParam{Key: p.key, Value: p.value}
+	}
+
+	return cursorState{
+		Version: _cursorStateVersion,
+		Path:    cur.path,
+		Params:  params,
+		Token:   cur.token,
+		Done:    cur.done,
+	}
+}
+
+// MarshalState serializes the cursor's position: the endpoint and
+// parameters it walks and the token of the next page. The format is JSON
+// and carries a version number. Restore it with LoadCursorState.
+func (cur *Cursor[T]) MarshalState() ([]byte, error) {
+	return json.Marshal(cur.state())
+}
+
+// LoadCursorState restores a position saved with MarshalState into cur, so
+// that cur.Next continues from the page after the one last fetched. Since
+// the options of a cursor aren't serialized, cur must be new and made by
+// the same constructor, with the same arguments and options, as the cursor
+// the state was saved from; LoadCursorState fails if its endpoint or
+// parameters differ.
+func LoadCursorState[T any](cur *Cursor[T], state []byte) error {
+	var saved cursorState
+	err := json.Unmarshal(state, &saved)
+	if err != nil {
+		return fmt.Errorf("unmarshal cursor state: %w", err)
+	}
+
+	if saved.Version != _cursorStateVersion {
+		return fmt.Errorf("unsupported cursor state version %d", saved.Version)
+	}
+
+	// Compare the endpoints through their JSON form, as saved's parameter
+	// values have been through a round trip.
+	want, err := json.Marshal(cursorState{Path: saved.Path, Params: saved.Params})
+	if err != nil {
+		return fmt.Errorf("marshal cursor state: %w", err)
+	}
+	got, err := json.Marshal(cursorState{Path: cur.path, Params: cur.state().Params})
+	if err != nil {
+		return fmt.Errorf("marshal cursor state: %w", err)
+	}
+	if !bytes.Equal(want, got) {
+		return errors.New("cursor state is for a different endpoint or parameters")
+	}
+
+	cur.token = saved.Token
+	cur.done = saved.Done
+	return nil
+}
+
+// UserTweetsCursor returns a cursor over a user's tweets. It accepts the
+// same options as GetUserTweets, and processes each page as
+// GetUserTweetsPage does.
+func (c *Client) UserTweetsCursor(userId string, opts ...getUserTweetsOption) *Cursor[Tweet] {
+	path, params, o := userTweetsRequest(userId, opts)
+	cur := newCursor[Tweet, getUserTweetsResponse](c, path, params, nil)
+	cur.page = func(ctx context.Context, c *Client, path []string, params []param, cont continuation, token string, _ func([]Tweet)) ([]Tweet, string, error) {
+		return c.userTweetsPage(ctx, path, params, cont, token, o)
+	}
+	return cur
+}
+
+// UserFollowingCursor returns a cursor over a user's following. It accepts
+// the same options as GetUserFollowing.
+func (c *Client) UserFollowingCursor(userId string, opts ...getUserFollowsOption) *Cursor[User] {
+	path, params, o := userFollowsRequest([]string{"user", "following"}, userId, opts)
+	return newCursor[User, getUserFollowsResponse](c, path, params, o.apply)
+}
+
+// UserFollowersCursor returns a cursor over a user's followers. It accepts
+// the same options as GetUserFollowers.
+func (c *Client) UserFollowersCursor(userId string, opts ...getUserFollowsOption) *Cursor[User] {
+	path, params, o := userFollowsRequest([]string{"user", "followers"}, userId, opts)
+	return newCursor[User, getUserFollowsResponse](c, path, params, o.apply)
+}
+
+// UserMediaCursor returns a cursor over a user's tweets with media.
+func (c *Client) UserMediaCursor(userId string) *Cursor[Tweet] {
+	path := []string{"user", "medias"}
+	params := []param{
+		{"user_id", userId},
+		{"limit", _pageLimit},
+	}
+
+	return newCursor[Tweet, getUserMediaResponse](c, path, params, nil)
+}
+
+// TweetRepliesCursor returns a cursor over the replies to a tweet. It
+// accepts the same options as GetTweetReplies, but ignores
+// WithAuthorRepliesOnly.
+func (c *Client) TweetRepliesCursor(tweetId string, opts ...getTweetRepliesOption) *Cursor[Tweet] {
+	path, params, o := tweetRepliesRequest(tweetId, opts)
+	return newCursor[Tweet, getTweetRepliesResponse](c, path, params, o.apply)
+}
+
+// TweetUserFavoritesCursor returns a cursor over the users who favorited a
+// tweet.
+func (c *Client) TweetUserFavoritesCursor(tweetId string) *Cursor[User] {
+	path := []string{"tweet", "favoriters"}
+	params := []param{
+		{"tweet_id", tweetId},
+	}
+
+	return newCursor[User, getUserFavoritesResponse](c, path, params, nil)
+}
+
+// SearchCursor returns a cursor over the tweets matching a query.
+func (c *Client) SearchCursor(query string) *Cursor[Tweet] {
+	path := []string{"search", "search"}
+	params := []param{
+		{"query", query},
+		{"limit", _pageLimit},
+	}
+
+	return newCursor[Tweet, getSearchResponse](c, path, params, nil)
+}
+
+// ListTweetsCursor returns a cursor over the tweets of a list's timeline.
+func (c *Client) ListTweetsCursor(listId string) *Cursor[Tweet] {
+	path := []string{"lists", "tweets"}
+	params := []param{
+		{"list_id", listId},
+		{"limit", _pageLimit},
+	}
+
+	return newCursor[Tweet, getListTweetsResponse](c, path, params, nil)
+}
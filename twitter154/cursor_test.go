@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	c, _ := newTestClient(t, tweetPages("/user/tweets", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}))
+	cur := c.UserTweetsCursor("783214")
+
+	var pages [][]string
+	for more := true; more; {
+		tweets, next, err := cur.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, tweetIds(tweets))
+		more = next
+	}
+
+	want := [][]string{{"5", "4"}, {"3", "2"}, {"1"}}
+	if len(pages) != len(want) {
+		t.Fatalf("pages = %v, want %v", pages, want)
+	}
+	for i := range want {
+		if !equalStrings(pages[i], want[i]) {
+			t.Errorf("page %d = %v, want %v", i, pages[i], want[i])
+		}
+	}
+
+	tweets, more, err := cur.Next(context.Background())
+	if err != nil || more || tweets == nil || len(tweets) != 0 {
+		t.Errorf("Next after the last page = %v, %v, %v", tweets, more, err)
+	}
+}
+
+func TestCursorRetry(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: tweetPage("p1", "2")}}},
+		{path: "/user/tweets/continuation", responses: []response{
+			{status: http.StatusInternalServerError},
+			{body: tweetPage("", "1")},
+		}},
+	})
+	cur := c.UserTweetsCursor("783214")
+
+	_, _, err := cur.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, more, err := cur.Next(context.Background())
+	if err == nil || !more {
+		t.Fatalf("Next = %v, %v, want a retryable error", more, err)
+	}
+
+	tweets, more, err := cur.Next(context.Background())
+	if err != nil || more || !equalStrings(tweetIds(tweets), []string{"1"}) {
+		t.Errorf("retried Next = %v, %v, %v", tweetIds(tweets), more, err)
+	}
+
+	sent := m.sentTo("/user/tweets/continuation")
+	if len(sent) != 2 || sent[1].URL.Query().Get("continuation_token") != "p1" {
+		t.Errorf("continuation requests = %d, want the failed page retried", len(sent))
+	}
+}
+
+func TestCursorRepeatedToken(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: tweetPage("same", "2")}}},
+		{path: "/user/tweets/continuation", responses: []response{{body: tweetPage("same", "1")}}},
+	})
+	cur := c.UserTweetsCursor("783214")
+
+	_, _, err := cur.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, more, err := cur.Next(context.Background())
+	if !errors.Is(err, ErrRepeatedToken) || more {
+		t.Errorf("Next = %v, %v, want ErrRepeatedToken", more, err)
+	}
+}
+
+func TestCursorTokenCycle(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/tweets", responses: []response{{body: tweetPage("a", "4")}}},
+		{path: "/user/tweets/continuation", query: map[string]string{"continuation_token": "a"}, responses: []response{{body: tweetPage("b", "3")}}},
+		{path: "/user/tweets/continuation", query: map[string]string{"continuation_token": "b"}, responses: []response{{body: tweetPage("a", "2")}}},
+	})
+
+	_, err := drain(c.UserTweetsCursor("783214"))
+	if !errors.Is(err, ErrRepeatedToken) {
+		t.Errorf("err = %v, want ErrRepeatedToken for a cycle of tokens", err)
+	}
+}
+
+func TestUserTweetsCursorProcessing(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"1","timestamp":100},
+		{"tweet_id":"4","timestamp":400,"in_reply_to_status_id":"9"},
+		{"tweet_id":"3","timestamp":300}
+	]}`
+	c, _ := newTestClient(t, []route{{path: "/user/tweets", responses: []response{{body: body}}}})
+
+	tweets, err := drain(c.UserTweetsCursor("783214", IncludePinned()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1", "3"}) || !tweets[0].LikelyPinned {
+		t.Errorf("tweets = %+v, want the reply dropped and the first tweet likely pinned", tweets)
+	}
+
+	c, _ = newTestClient(t, []route{{path: "/user/tweets", responses: []response{{status: http.StatusNotFound}}}})
+	tweets, err = drain(c.UserTweetsCursor("783214", WithTreatNotFoundAsEmpty()))
+	if err != nil || len(tweets) != 0 {
+		t.Errorf("first page 404 = %v, %v, want an empty timeline", tweets, err)
+	}
+}
+
+func TestCursorFilter(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	users, err := drain(c.UserFollowingCursor("783214", WithVerifiedOnly()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Errorf("got %d users, want the 2 verified", len(users))
+	}
+}
+
+func TestCursorState(t *testing.T) {
+	routes := tweetPages("/user/tweets", []string{"5", "4"}, []string{"3", "2"}, []string{"1"})
+
+	c, _ := newTestClient(t, routes)
+	cur := c.UserTweetsCursor("783214", WithSinceId("1"))
+	_, _, err := cur.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := cur.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resume in a new client, as a restarted process would.
+	c, m := newTestClient(t, routes)
+	resumed := c.UserTweetsCursor("783214", WithSinceId("1"))
+	err = LoadCursorState(resumed, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tweets, err := drain(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "2", "1"}) {
+		t.Errorf("resumed tweet ids = %v", ids)
+	}
+	if n := len(m.sentTo("/user/tweets")); n != 0 {
+		t.Errorf("resumed cursor refetched the first page")
+	}
+
+	// A finished cursor stays finished.
+	state, err = resumed.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := c.UserTweetsCursor("783214", WithSinceId("1"))
+	err = LoadCursorState(done, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweets, more, err := done.Next(context.Background())
+	if err != nil || more || len(tweets) != 0 {
+		t.Errorf("Next on a finished cursor = %v, %v, %v", tweets, more, err)
+	}
+}
+
+func TestLoadCursorStateMismatch(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	state, err := c.UserTweetsCursor("783214").MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cur := range []*Cursor[Tweet]{
+		c.UserTweetsCursor("12"),
+		c.UserTweetsCursor("783214", IncludeReplies()),
+		c.UserMediaCursor("783214"),
+	} {
+		if err := LoadCursorState(cur, state); err == nil {
+			t.Errorf("state loaded into a cursor over %v %v", cur.path, cur.params)
+		}
+	}
+
+	bad := strings.Replace(string(state), `"version":1`, `"version":99`, 1)
+	if err := LoadCursorState(c.UserTweetsCursor("783214"), []byte(bad)); err == nil {
+		t.Error("state with an unknown version loaded")
+	}
+	if err := LoadCursorState(c.UserTweetsCursor("783214"), []byte("not json")); err == nil {
+		t.Error("invalid state loaded")
+	}
+}
@@ -0,0 +1,523 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// drain fetches every page of cur.
+func drain[T any](cur *Cursor[T]) ([]T, error) {
+	var results []T
+	for more := true; more; {
+		var page []T
+		var err error
+		page, more, err = cur.Next(context.Background())
+		if err != nil {
+			return results, err
+		}
+		results = append(results, page...)
+	}
+	return results, nil
+}
+
+// _endpointTests calls every public method that makes a request against
+// the registered fixtures. check is given the method's result and asserts
+// that it carries the fixture's data; the client validates every response
+// with WithValidateResponses, so results also have their IDs.
+var _endpointTests = []struct {
+	name  string
+	call  func(c *Client) (any, error)
+	check func(t *testing.T, result any)
+}{
+	{
+		name: "GetUsername",
+		call: func(c *Client) (any, error) { return c.GetUsername("783214") },
+		check: func(t *testing.T, result any) {
+			if username := result.(string); username != "X" {
+				t.Errorf("username = %q, want X", username)
+			}
+		},
+	},
+	{
+		name: "Ping",
+		call: func(c *Client) (any, error) { return nil, c.Ping(context.Background()) },
+	},
+	{
+		name: "Raw",
+		call: func(c *Client) (any, error) {
+			return c.Raw(context.Background(), []string{"user", "details"}, map[string]string{"user_id": "783214"})
+		},
+		check: func(t *testing.T, result any) {
+			if body := result.([]byte); string(body) != fixture(t, "user_details") {
+				t.Errorf("body = %s, want the fixture", body)
+			}
+		},
+	},
+	{
+		name: "GetUser",
+		call: func(c *Client) (any, error) { return c.GetUser("783214") },
+		check: func(t *testing.T, result any) {
+			user := result.(User)
+			if user.Username != "X" || user.FollowerCount != 66947089 || user.Likes() != 5918 {
+				t.Errorf("user = %+v", user)
+			}
+			if user.Category == nil || user.Category.Name != "Technology" {
+				t.Errorf("category = %+v, want Technology", user.Category)
+			}
+		},
+	},
+	{
+		name: "GetUserByUsername",
+		call: func(c *Client) (any, error) { return c.GetUserByUsername("X") },
+		check: func(t *testing.T, result any) {
+			if user := result.(User); user.UserId != "783214" {
+				t.Errorf("user id = %q, want 783214", user.UserId)
+			}
+		},
+	},
+	{
+		name: "GetUsersByUsername",
+		call: func(c *Client) (any, error) {
+			users, errs := c.GetUsersByUsername([]string{"@X", "x"})
+			return users, errors.Join(errs...)
+		},
+		check: func(t *testing.T, result any) {
+			if ids := userIds(result.([]User)); !equalStrings(ids, []string{"783214", "783214"}) {
+				t.Errorf("user ids = %v", ids)
+			}
+		},
+	},
+	{
+		name: "GetUserTweets",
+		call: func(c *Client) (any, error) { return c.GetUserTweets("783214") },
+		check: func(t *testing.T, result any) {
+			want := []string{"1707817016484364288", "1707491640197976567", "1706790468937093377"}
+			if ids := tweetIds(result.([]Tweet)); !equalStrings(ids, want) {
+				t.Errorf("tweet ids = %v, want %v", ids, want)
+			}
+		},
+	},
+	{
+		name: "GetUserTweetsPage",
+		call: func(c *Client) (any, error) {
+			tweets, next, err := c.GetUserTweetsPage("783214", "")
+			return []any{tweets, next}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if tweets := r[0].([]Tweet); len(tweets) != 2 || tweets[1].FavoriteCount != 2817 {
+				t.Errorf("tweets = %+v", tweets)
+			}
+			if next := r[1].(string); next == "" {
+				t.Error("no next token")
+			}
+		},
+	},
+	{
+		name: "GetUserTweetsWithProfile",
+		call: func(c *Client) (any, error) {
+			user, tweets, err := c.GetUserTweetsWithProfile("783214")
+			return []any{user, tweets}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if user := r[0].(User); user.Username != "X" {
+				t.Errorf("user = %+v", user)
+			}
+			if tweets := r[1].([]Tweet); len(tweets) != 3 {
+				t.Errorf("got %d tweets, want 3", len(tweets))
+			}
+		},
+	},
+	{
+		name: "UserTweetsCursor",
+		call: func(c *Client) (any, error) { return drain(c.UserTweetsCursor("783214")) },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 3 {
+				t.Errorf("got %d tweets, want 3", len(tweets))
+			}
+		},
+	},
+	{
+		name: "GetUserFollowing",
+		call: func(c *Client) (any, error) { return c.GetUserFollowing("783214") },
+		check: func(t *testing.T, result any) {
+			users := result.([]User)
+			if len(users) != 3 || users[1].FollowerCount != 7302664 {
+				t.Errorf("users = %+v", users)
+			}
+		},
+	},
+	{
+		name: "GetUserFollowingPage",
+		call: func(c *Client) (any, error) {
+			users, _, total, err := c.GetUserFollowingPage("783214", "")
+			return []any{users, total}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if users := r[0].([]User); len(users) != 3 {
+				t.Errorf("got %d users, want 3", len(users))
+			}
+			if total := r[1].(int); total != 4 {
+				t.Errorf("total = %d, want 4", total)
+			}
+		},
+	},
+	{
+		name: "UserFollowingCursor",
+		call: func(c *Client) (any, error) { return drain(c.UserFollowingCursor("783214")) },
+		check: func(t *testing.T, result any) {
+			if users := result.([]User); len(users) != 3 {
+				t.Errorf("got %d users, want 3", len(users))
+			}
+		},
+	},
+	{
+		name: "GetUserFollowers",
+		call: func(c *Client) (any, error) { return c.GetUserFollowers("783214") },
+		check: func(t *testing.T, result any) {
+			if ids := userIds(result.([]User)); !equalStrings(ids, []string{"1603785453187919873", "12"}) {
+				t.Errorf("user ids = %v", ids)
+			}
+		},
+	},
+	{
+		name: "GetUserFollowersPage",
+		call: func(c *Client) (any, error) {
+			users, _, total, err := c.GetUserFollowersPage("783214", "")
+			return []any{users, total}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if users := r[0].([]User); len(users) != 2 {
+				t.Errorf("got %d users, want 2", len(users))
+			}
+			if total := r[1].(int); total != 66947089 {
+				t.Errorf("total = %d, want 66947089", total)
+			}
+		},
+	},
+	{
+		name: "UserFollowersCursor",
+		call: func(c *Client) (any, error) { return drain(c.UserFollowersCursor("783214")) },
+		check: func(t *testing.T, result any) {
+			if users := result.([]User); len(users) != 2 {
+				t.Errorf("got %d users, want 2", len(users))
+			}
+		},
+	},
+	{
+		name: "Mutuals",
+		call: func(c *Client) (any, error) { return c.Mutuals("783214", "12") },
+		check: func(t *testing.T, result any) {
+			if users := result.([]User); len(users) != 3 {
+				t.Errorf("got %d users, want 3", len(users))
+			}
+		},
+	},
+	{
+		name: "GetUserMedia",
+		call: func(c *Client) (any, error) { return c.GetUserMedia("783214") },
+		check: func(t *testing.T, result any) {
+			tweets := result.([]Tweet)
+			if len(tweets) != 2 || !tweets[0].HasMedia() || !tweets[1].HasMedia() {
+				t.Errorf("tweets = %+v", tweets)
+			}
+		},
+	},
+	{
+		name: "GetUserMediaGrouped",
+		call: func(c *Client) (any, error) {
+			photos, videos, err := c.GetUserMediaGrouped("783214")
+			return []any{photos, videos}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if photos := r[0].([]Media); len(photos) != 1 || photos[0].AltText == "" {
+				t.Errorf("photos = %+v", photos)
+			}
+			if videos := r[1].([]Media); len(videos) != 2 {
+				t.Errorf("got %d videos, want 2", len(videos))
+			}
+		},
+	},
+	{
+		name: "UserMediaCursor",
+		call: func(c *Client) (any, error) { return drain(c.UserMediaCursor("783214")) },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 2 {
+				t.Errorf("got %d tweets, want 2", len(tweets))
+			}
+		},
+	},
+	{
+		name: "GetTweetDetails",
+		call: func(c *Client) (any, error) { return c.GetTweetDetails("1707913395413270958") },
+		check: func(t *testing.T, result any) {
+			tweet := result.(Tweet)
+			if tweet.User.Username != "X" || tweet.Views != 3302117 {
+				t.Errorf("tweet = %+v", tweet)
+			}
+			if tweet.FullText() == tweet.Text {
+				t.Error("FullText is the truncated text")
+			}
+		},
+	},
+	{
+		name: "GetTweetByURL",
+		call: func(c *Client) (any, error) {
+			return c.GetTweetByURL(context.Background(), "https://x.com/X/status/1707913395413270958")
+		},
+		check: func(t *testing.T, result any) {
+			if tweet := result.(Tweet); tweet.TweetId != "1707913395413270958" {
+				t.Errorf("tweet id = %q", tweet.TweetId)
+			}
+		},
+	},
+	{
+		name: "IsTweetAvailable",
+		call: func(c *Client) (any, error) { return c.IsTweetAvailable("1707913395413270958") },
+		check: func(t *testing.T, result any) {
+			if !result.(bool) {
+				t.Error("tweet is unavailable")
+			}
+		},
+	},
+	{
+		name: "GetTweetWithParent",
+		call: func(c *Client) (any, error) {
+			tweet, parent, err := c.GetTweetWithParent("1707913395413270958")
+			return []any{tweet, parent}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if tweet := r[0].(Tweet); tweet.TweetId != "1707913395413270958" {
+				t.Errorf("tweet id = %q", tweet.TweetId)
+			}
+			if parent := r[1].(*Tweet); parent != nil {
+				t.Errorf("parent = %+v, want nil", parent)
+			}
+		},
+	},
+	{
+		name: "GetTweetReplies",
+		call: func(c *Client) (any, error) { return c.GetTweetReplies("1707913395413270958") },
+		check: func(t *testing.T, result any) {
+			want := []string{"1707913815258898618", "1707914021543526710", "1707915003455561815"}
+			if ids := tweetIds(result.([]Tweet)); !equalStrings(ids, want) {
+				t.Errorf("reply ids = %v, want %v", ids, want)
+			}
+		},
+	},
+	{
+		name: "GetTweetRepliesPage",
+		call: func(c *Client) (any, error) {
+			replies, next, err := c.GetTweetRepliesPage("1707913395413270958", "")
+			return []any{replies, next}, err
+		},
+		check: func(t *testing.T, result any) {
+			r := result.([]any)
+			if replies := r[0].([]Tweet); len(replies) != 2 {
+				t.Errorf("got %d replies, want 2", len(replies))
+			}
+			if next := r[1].(string); next == "" {
+				t.Error("no next token")
+			}
+		},
+	},
+	{
+		name: "TweetRepliesCursor",
+		call: func(c *Client) (any, error) { return drain(c.TweetRepliesCursor("1707913395413270958")) },
+		check: func(t *testing.T, result any) {
+			if replies := result.([]Tweet); len(replies) != 3 {
+				t.Errorf("got %d replies, want 3", len(replies))
+			}
+		},
+	},
+	{
+		name: "GetTweetUserFavorites",
+		call: func(c *Client) (any, error) { return c.GetTweetUserFavorites("1707913395413270958") },
+		check: func(t *testing.T, result any) {
+			if ids := userIds(result.([]User)); !equalStrings(ids, []string{"12", "44196397"}) {
+				t.Errorf("user ids = %v", ids)
+			}
+		},
+	},
+	{
+		name: "TweetUserFavoritesCursor",
+		call: func(c *Client) (any, error) { return drain(c.TweetUserFavoritesCursor("1707913395413270958")) },
+		check: func(t *testing.T, result any) {
+			if users := result.([]User); len(users) != 2 {
+				t.Errorf("got %d users, want 2", len(users))
+			}
+		},
+	},
+	{
+		name: "Search",
+		call: func(c *Client) (any, error) { return c.Search("golang") },
+		check: func(t *testing.T, result any) {
+			tweets := result.([]Tweet)
+			if len(tweets) != 3 || tweets[0].RetweetStatus == nil || tweets[1].QuotedStatus == nil {
+				t.Errorf("tweets = %+v", tweets)
+			}
+		},
+	},
+	{
+		name: "SearchCount",
+		call: func(c *Client) (any, error) { return c.SearchCount("golang", 2) },
+		check: func(t *testing.T, result any) {
+			if count := result.(int); count != 2 {
+				t.Errorf("count = %d, want 2", count)
+			}
+		},
+	},
+	{
+		name: "SearchEach",
+		call: func(c *Client) (any, error) {
+			var tweets []Tweet
+			err := c.SearchEach(context.Background(), "golang", func(t Tweet) error {
+				tweets = append(tweets, t)
+				return nil
+			})
+			return tweets, err
+		},
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 3 {
+				t.Errorf("got %d tweets, want 3", len(tweets))
+			}
+		},
+	},
+	{
+		name: "SearchCursor",
+		call: func(c *Client) (any, error) { return drain(c.SearchCursor("golang")) },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 3 {
+				t.Errorf("got %d tweets, want 3", len(tweets))
+			}
+		},
+	},
+	{
+		name: "NewSearch",
+		call: func(c *Client) (any, error) { return c.NewSearch().Query("golang").Do(context.Background()) },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 3 {
+				t.Errorf("got %d tweets, want 3", len(tweets))
+			}
+		},
+	},
+	{
+		name: "GetListDetails",
+		call: func(c *Client) (any, error) { return c.GetListDetails("1591033111726391297") },
+		check: func(t *testing.T, result any) {
+			list := result.(List)
+			if list.Name != "testing" || list.MemberCount != 8 || !list.IsPublic() || list.User.Username != "previewuser" {
+				t.Errorf("list = %+v", list)
+			}
+		},
+	},
+	{
+		name: "GetListByURL",
+		call: func(c *Client) (any, error) {
+			return c.GetListByURL(context.Background(), "https://x.com/i/lists/1591033111726391297")
+		},
+		check: func(t *testing.T, result any) {
+			if list := result.(List); list.ListId != "1591033111726391297" {
+				t.Errorf("list id = %q", list.ListId)
+			}
+		},
+	},
+	{
+		name: "GetListTweets",
+		call: func(c *Client) (any, error) { return c.GetListTweets("1591033111726391297") },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 1 {
+				t.Errorf("got %d tweets, want 1", len(tweets))
+			}
+		},
+	},
+	{
+		name: "ListTweetsCursor",
+		call: func(c *Client) (any, error) { return drain(c.ListTweetsCursor("1591033111726391297")) },
+		check: func(t *testing.T, result any) {
+			if tweets := result.([]Tweet); len(tweets) != 1 {
+				t.Errorf("got %d tweets, want 1", len(tweets))
+			}
+		},
+	},
+	{
+		name: "GetLocations",
+		call: func(c *Client) (any, error) { return c.GetLocations() },
+		check: func(t *testing.T, result any) {
+			locations := result.([]Location)
+			if len(locations) != 5 || locations[1].WoeId != WOEIDLondon || locations[1].CountryCode != "GB" {
+				t.Errorf("locations = %+v", locations)
+			}
+		},
+	},
+	{
+		name: "FindWOEID",
+		call: func(c *Client) (any, error) { return c.FindWOEID("new york") },
+		check: func(t *testing.T, result any) {
+			if woeId := result.(int); woeId != WOEIDNewYork {
+				t.Errorf("woeid = %d, want %d", woeId, WOEIDNewYork)
+			}
+		},
+	},
+	{
+		name: "GetTrends",
+		call: func(c *Client) (any, error) { return c.GetTrends(WOEIDWorldwide) },
+		check: func(t *testing.T, result any) {
+			trends := result.([]Trend)
+			if len(trends) != 2 || trends[0].Name != "#GoLang" || trends[0].TweetVolume != 18342 || trends[1].TweetVolume != 0 {
+				t.Errorf("trends = %+v", trends)
+			}
+		},
+	},
+}
+
+// TestEndpointFixtures checks that every public method that makes a
+// request decodes the recorded response of its endpoint.
+func TestEndpointFixtures(t *testing.T) {
+	for _, tt := range _endpointTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newFixtureClient(t, WithValidateResponses())
+
+			result, err := tt.call(c)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if tt.check != nil {
+				tt.check(t, result)
+			}
+		})
+	}
+}
+
+// TestFixturesRegistered checks that every fixture in testdata is
+// registered for an endpoint and is valid JSON.
+func TestFixturesRegistered(t *testing.T) {
+	registered := make(map[string]bool, len(_fixtures))
+	for _, name := range _fixtures {
+		registered[name] = true
+	}
+
+	entries, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if !registered[name] {
+			t.Errorf("fixture %s is not registered", name)
+		}
+		if !json.Valid([]byte(fixture(t, name))) {
+			t.Errorf("fixture %s is not valid JSON", name)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind classifies an APIError by its cause.
+type ErrorKind int
+
+const (
+	KindUnknown ErrorKind = iota
+	KindBadRequest
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindRateLimited
+	KindServer
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindBadRequest:
+		return "bad request"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not found"
+	case KindRateLimited:
+		return "rate limited"
+	case KindServer:
+		return "server error"
+	default:
+		return "unknown"
+	}
+}
+
+// Sentinels matching an *APIError of the corresponding kind with errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+var _kindSentinels = map[ErrorKind]error{
+	KindBadRequest:   ErrBadRequest,
+	KindUnauthorized: ErrUnauthorized,
+	KindForbidden:    ErrForbidden,
+	KindNotFound:     ErrNotFound,
+	KindRateLimited:  ErrRateLimited,
+	KindServer:       ErrServer,
+}
+
+// APIError is returned when the API responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	// Message is the "message" field of a JSON error body, or the raw body
+	// if it isn't JSON.
+	Message string
+
+	// kind is the kind of an error body sent with a 2xx status, whose
+	// status code doesn't tell.
+	kind ErrorKind
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status code %d: %s", e.StatusCode, e.Message)
+}
+
+// Kind classifies the error by its status code or, for an error body sent
+// with a 2xx status, by its message.
+func (e *APIError) Kind() ErrorKind {
+	if e.kind != KindUnknown {
+		return e.kind
+	}
+
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return KindBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return KindUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return KindForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return KindNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return KindRateLimited
+	case e.StatusCode >= 500:
+		return KindServer
+	default:
+		return KindUnknown
+	}
+}
+
+// Is reports whether target is the sentinel of the error's kind, so that
+// errors.Is(err, ErrNotFound) holds for a wrapped 404 APIError.
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := _kindSentinels[e.Kind()]
+	return ok && target == sentinel
+}
+
+// IsRateLimited reports whether err is or wraps an APIError caused by rate
+// limiting.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsNotFound reports whether err is or wraps an APIError for a resource that
+// doesn't exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	var r struct {
+		Message string `json:"message"`
+	}
+
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &r) == nil && r.Message != "" {
+		message = r.Message
+	}
+
+	return &APIError{
+		StatusCode: statusCode,
+		Message:    message,
+	}
+}
+
+// newErrorBodyError returns an *APIError if a successful response body is
+// nonetheless an error object, i.e. a JSON object with an "error" or
+// "message" field, and nil otherwise.
+func newErrorBodyError(statusCode int, body []byte) *APIError {
+	var r struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+
+	if json.Unmarshal(body, &r) != nil {
+		return nil
+	}
+
+	var message string
+	if json.Unmarshal(r.Error, &message) != nil || message == "" {
+		message = r.Message
+	}
+	if message == "" && len(r.Error) != 0 && string(r.Error) != "null" {
+		message = string(r.Error)
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	return &APIError{
+		StatusCode: statusCode,
+		Message:    message,
+		kind:       messageKind(message),
+	}
+}
+
+// _messageKinds maps phrases of the messages of error bodies sent with a 2xx
+// status to the kind of error they report, checked in order.
+var _messageKinds = []struct {
+	phrase string
+	kind   ErrorKind
+}{
+	{"rate limit", KindRateLimited},
+	{"too many requests", KindRateLimited},
+	{"exceeded the monthly quota", KindRateLimited},
+	{"invalid api key", KindUnauthorized},
+	{"not authorized", KindUnauthorized},
+	{"unauthorized", KindUnauthorized},
+	{"not subscribed", KindForbidden},
+	{"forbidden", KindForbidden},
+	{"not found", KindNotFound},
+	{"does not exist", KindNotFound},
+	{"internal server error", KindServer},
+	{"service unavailable", KindServer},
+	{"timed out", KindServer},
+	{"bad request", KindBadRequest},
+}
+
+// messageKind classifies an error body's message, or returns KindUnknown
+// if no known phrase occurs in it.
+func messageKind(message string) ErrorKind {
+	message = strings.ToLower(message)
+	for _, k := range _messageKinds {
+		if strings.Contains(message, k.phrase) {
+			return k.kind
+		}
+	}
+	return KindUnknown
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+		kind    ErrorKind
+		is      error
+	}{
+		{
+			name:    "json",
+			status:  http.StatusTooManyRequests,
+			body:    `{"message":"You have exceeded the rate limit per second for your plan, BASIC, by the API provider"}`,
+			message: "You have exceeded the rate limit per second for your plan, BASIC, by the API provider",
+			kind:    KindRateLimited,
+			is:      ErrRateLimited,
+		},
+		{
+			name:    "plaintext",
+			status:  http.StatusBadGateway,
+			body:    "Bad Gateway\n",
+			message: "Bad Gateway",
+			kind:    KindServer,
+			is:      ErrServer,
+		},
+		{
+			name:    "json without message",
+			status:  http.StatusNotFound,
+			body:    `{"detail":"gone"}`,
+			message: `{"detail":"gone"}`,
+			kind:    KindNotFound,
+			is:      ErrNotFound,
+		},
+		{
+			name:   "empty",
+			status: http.StatusUnauthorized,
+			kind:   KindUnauthorized,
+			is:     ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(t, []route{
+				{path: "/user/details", responses: []response{{status: tt.status, body: tt.body}}},
+			})
+
+			_, err := c.GetUser("783214")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.message || apiErr.Kind() != tt.kind {
+				t.Errorf("APIError = %+v, kind %s", apiErr, apiErr.Kind())
+			}
+			if !errors.Is(err, tt.is) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.is)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	err := fmt.Errorf("outer: %w", fmt.Errorf("get: %w", &APIError{StatusCode: http.StatusNotFound}))
+
+	if !errors.Is(err, ErrNotFound) || !IsNotFound(err) {
+		t.Error("wrapped 404 isn't ErrNotFound")
+	}
+	if errors.Is(err, ErrRateLimited) || IsRateLimited(err) {
+		t.Error("wrapped 404 is ErrRateLimited")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("errors.As = %v", apiErr)
+	}
+
+	for status, kind := range map[int]ErrorKind{
+		400: KindBadRequest,
+		401: KindUnauthorized,
+		403: KindForbidden,
+		404: KindNotFound,
+		429: KindRateLimited,
+		503: KindServer,
+		418: KindUnknown,
+	} {
+		e := &APIError{StatusCode: status}
+		if e.Kind() != kind {
+			t.Errorf("status %d: kind = %s, want %s", status, e.Kind(), kind)
+		}
+		if kind == KindUnknown && errors.Is(e, ErrServer) {
+			t.Errorf("status %d is ErrServer", status)
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	errorBody := `{"error":"Not authorized."}`
+
+	c, _ := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: errorBody}}},
+		{path: "/user/tweets", responses: []response{{body: tweetPage("p1", "2")}}},
+		{path: "/user/tweets/continuation", responses: []response{{body: errorBody}}},
+		{path: "/custom/endpoint", responses: []response{{body: `{"message":"hello","error":null}`}}},
+	}, WithPartialResults())
+
+	var apiErr *APIError
+	_, err := c.GetUser("783214")
+	if !errors.As(err, &apiErr) || apiErr.Message != "Not authorized." {
+		t.Errorf("GetUser err = %v, want the error body's message", err)
+	}
+
+	tweets, err := c.GetUserTweets("783214")
+	if !errors.As(err, &apiErr) {
+		t.Errorf("GetUserTweets err = %v, want an *APIError for the second page", err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"2"}) {
+		t.Errorf("tweet ids = %v, want the first page", ids)
+	}
+
+	_, _, err = c.GetUserTweetsPage("783214", "p1")
+	if !errors.As(err, &apiErr) {
+		t.Errorf("GetUserTweetsPage err = %v, want an *APIError", err)
+	}
+
+	// Raw returns the body undecoded, so it isn't checked.
+	data, err := c.Raw(context.Background(), []string{"custom", "endpoint"}, nil)
+	if err != nil || string(data) != `{"message":"hello","error":null}` {
+		t.Errorf("Raw = %s, %v", data, err)
+	}
+}
+
+func TestErrorBodyKind(t *testing.T) {
+	tests := []struct {
+		message string
+		kind    ErrorKind
+		is      error
+	}{
+		{"You have exceeded the rate limit per second for your plan, BASIC, by the API provider", KindRateLimited, ErrRateLimited},
+		{"Invalid API key. Go to https://docs.rapidapi.com/docs/keys for more info.", KindUnauthorized, ErrUnauthorized},
+		{"You are not subscribed to this API.", KindForbidden, ErrForbidden},
+		{"User not found", KindNotFound, ErrNotFound},
+		{"Internal Server Error", KindServer, ErrServer},
+		{"Something went wrong", KindUnknown, nil},
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"message":%q}`, tt.message)
+		c, _ := newTestClient(t, []route{
+			{path: "/user/details", responses: []response{{status: http.StatusNonAuthoritativeInfo, body: body}}},
+		})
+
+		_, err := c.GetUser("783214")
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%q: err = %v, want an *APIError", tt.message, err)
+		}
+		if apiErr.StatusCode != http.StatusNonAuthoritativeInfo || apiErr.Kind() != tt.kind {
+			t.Errorf("%q: status %d, kind %s, want the response's status and kind %s", tt.message, apiErr.StatusCode, apiErr.Kind(), tt.kind)
+		}
+		if tt.is != nil && !errors.Is(err, tt.is) {
+			t.Errorf("%q: errors.Is(%v, %v) = false", tt.message, err, tt.is)
+		}
+	}
+}
+
+func TestErrorBodyRetried(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/username", responses: []response{
+			{body: `{"message":"Too many requests"}`},
+			{body: fixture(t, "user_username")},
+		}},
+	}, WithBackoff(ConstantBackoff(time.Millisecond), 2))
+
+	if _, err := c.GetUsername("783214"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want a rate limit error body retried", n)
+	}
+
+	c, m = newTestClient(t, []route{
+		{host: "a.example.com", path: "/user/username", responses: []response{{body: `{"error":"Internal Server Error"}`}}},
+		{host: "b.example.com", path: "/user/username", responses: []response{{body: fixture(t, "user_username")}}},
+	}, WithHosts("a.example.com", "b.example.com"))
+
+	if _, err := c.GetUsername("783214"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want a server error body failed over", n)
+	}
+}
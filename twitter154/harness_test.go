@@ -0,0 +1,381 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// response is a canned HTTP response served by mockTransport.
+type response struct {
+	// status is the status code, 200 if zero.
+	status int
+	header map[string]string
+	body   string
+	// delay holds the response back until it passes or the request's
+	// context is done.
+	delay time.Duration
+	// err fails the round trip instead, as a connection error would.
+	err error
+}
+
+// route serves the requests to path whose query has every param in
+// query. Successive requests get successive responses, and once they run
+// out the last one is repeated. A non-empty host restricts the route to
+// that host.
+type route struct {
+	host      string
+	path      string
+	query     map[string]string
+	responses []response
+}
+
+func (r route) matches(req *http.Request) bool {
+	if r.host != "" && req.URL.Host != r.host {
+		return false
+	}
+	if req.URL.Path != r.path {
+		return false
+	}
+
+	query := req.URL.Query()
+	for k, v := range r.query {
+		if query.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// mockTransport is an http.RoundTripper that serves routes instead of
+// making requests, and records the requests it is sent.
+type mockTransport struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	routes   []route
+	served   []int
+	requests []*http.Request
+	// inFlight counts the round trips in progress, and maxInFlight the
+	// most there have been at once.
+	inFlight    int
+	maxInFlight int
+}
+
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, req.Clone(req.Context()))
+
+	// The route with the most query params wins, so a route for a single
+	// continuation token overrides the route for the endpoint.
+	best := -1
+	for i, r := range m.routes {
+		if r.matches(req) && (best < 0 || len(r.query) > len(m.routes[best].query)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		m.mu.Unlock()
+		m.t.Errorf("no route for %s %s", req.Method, req.URL)
+		return nil, fmt.Errorf("no route for %s", req.URL)
+	}
+
+	responses := m.routes[best].responses
+	resp := responses[len(responses)-1]
+	if n := m.served[best]; n < len(responses) {
+		resp = responses[n]
+	}
+	m.served[best]++
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+
+	if resp.delay > 0 {
+		timer := time.NewTimer(resp.delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if resp.err != nil {
+		return nil, resp.err
+	}
+
+	status := resp.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := make(http.Header)
+	for k, v := range resp.header {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.body)),
+		ContentLength: int64(len(resp.body)),
+		Request:       req,
+	}, nil
+}
+
+// sent returns the requests sent so far.
+func (m *mockTransport) sent() []*http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*http.Request(nil), m.requests...)
+}
+
+// sentTo returns the requests sent so far to path.
+func (m *mockTransport) sentTo(path string) []*http.Request {
+	var requests []*http.Request
+	for _, req := range m.sent() {
+		if req.URL.Path == path {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// newTestClient returns a client whose requests are served by routes,
+// with opts applied after the mock HTTP client is installed.
+func newTestClient(t *testing.T, routes []route, opts ...option) (*Client, *mockTransport) {
+	t.Helper()
+
+	m := &mockTransport{
+		t:      t,
+		routes: routes,
+		served: make([]int, len(routes)),
+	}
+
+	c, err := New("test-key", append([]option{WithHttpClient(http.Client{Transport: m})}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return &c, m
+}
+
+// fixture returns the recorded response body in testdata/name.json.
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return string(data)
+}
+
+// _fixtures registers the recorded response of each endpoint, by path,
+// with the fixture of its first page and, for paginated endpoints whose
+// first page has a continuation token, of its continuation page. An
+// endpoint a method requests must be registered here for the method to
+// be covered by TestEndpointFixtures.
+var _fixtures = map[string]string{
+	"/user/username":              "user_username",
+	"/user/details":               "user_details",
+	"/user/tweets":                "user_tweets",
+	"/user/tweets/continuation":   "user_tweets_continuation",
+	"/user/following":             "user_following",
+	"/user/followers":             "user_followers",
+	"/user/medias":                "user_medias",
+	"/tweet/details":              "tweet_details",
+	"/tweet/replies":              "tweet_replies",
+	"/tweet/replies/continuation": "tweet_replies_continuation",
+	"/tweet/favoriters":           "tweet_favoriters",
+	"/search/search":              "search_search",
+	"/lists/details":              "lists_details",
+	"/lists/tweets":               "lists_tweets",
+	"/trends":                     "trends",
+	"/trends/available":           "trends_available",
+}
+
+// fixtureRoutes returns a route serving the registered fixture of each
+// endpoint.
+func fixtureRoutes(t *testing.T) []route {
+	t.Helper()
+
+	routes := make([]route, 0, len(_fixtures))
+	for path, name := range _fixtures {
+		routes = append(routes, route{
+			path:      path,
+			responses: []response{{body: fixture(t, name)}},
+		})
+	}
+	return routes
+}
+
+// newFixtureClient returns a client whose requests are served by the
+// registered fixtures.
+func newFixtureClient(t *testing.T, opts ...option) (*Client, *mockTransport) {
+	t.Helper()
+
+	return newTestClient(t, fixtureRoutes(t), opts...)
+}
+
+// tweetPage returns the body of a page of tweets with the given IDs, with
+// timestamps decreasing from the first, and the continuation token token.
+func tweetPage(token string, ids ...string) string {
+	var b bytes.Buffer
+	b.WriteString(`{"results":[`)
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"tweet_id":%q,"user":{"user_id":"783214"},"timestamp":%d}`, id, 1700000000-i)
+	}
+	fmt.Fprintf(&b, `],"continuation_token":%q}`, token)
+	return b.String()
+}
+
+// userPage returns the body of a page of users with the given IDs and the
+// continuation token token.
+func userPage(token string, ids ...string) string {
+	var b bytes.Buffer
+	b.WriteString(`{"results":[`)
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"user_id":%q,"username":"user%s"}`, id, id)
+	}
+	fmt.Fprintf(&b, `],"continuation_token":%q}`, token)
+	return b.String()
+}
+
+// tweetIds returns the IDs of tweets.
+func tweetIds(tweets []Tweet) []string {
+	ids := make([]string, len(tweets))
+	for i, t := range tweets {
+		ids[i] = t.TweetId
+	}
+	return ids
+}
+
+// userIds returns the IDs of users.
+func userIds(users []User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.UserId
+	}
+	return ids
+}
+
+// equalStrings reports whether a and b hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// tweetPages returns routes serving pages of tweets with the given IDs
+// from the endpoint at path, the first page at path and the others at its
+// continuation sub-path. Page i links to page i+1 with the token "p<i+1>";
+// the last page has no token.
+func tweetPages(path string, pages ...[]string) []route {
+	routes := make([]route, len(pages))
+	for i, ids := range pages {
+		token := ""
+		if i+1 < len(pages) {
+			token = fmt.Sprintf("p%d", i+1)
+		}
+
+		routes[i] = route{
+			path:      path,
+			responses: []response{{body: tweetPage(token, ids...)}},
+		}
+		if i > 0 {
+			routes[i].path = path + "/continuation"
+			routes[i].query = map[string]string{"continuation_token": fmt.Sprintf("p%d", i)}
+		}
+	}
+	return routes
+}
+
+// fakeClock is a Clock whose time moves only when it is waited on, by the
+// duration waited, and that records each wait.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Sleep(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.waits = append(f.waits, d)
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.Sleep(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- f.Now()
+	return ch
+}
+
+// waited returns the durations waited so far.
+func (f *fakeClock) waited() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]time.Duration(nil), f.waits...)
+}
+
+// equalDurations reports whether a and b hold the same durations in the
+// same order.
+func equalDurations(a, b []time.Duration) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
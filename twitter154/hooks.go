@@ -0,0 +1,30 @@
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// RequestInfo describes a request made by the client, as passed to the hook
+// installed with WithObserver.
+type RequestInfo struct {
+	Method string
+	URL    string
+	// RequestID is the X-Request-ID header sent with the request, if
+	// WithRequestID is used.
+	RequestID string
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// newRequestId returns a random version 4 UUID.
+func newRequestId() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestResponseHook(t *testing.T) {
+	var paths []string
+	var bodies []string
+	c, _ := newFixtureClient(t, WithResponseHook(func(path string, body []byte) {
+		paths = append(paths, path)
+		bodies = append(bodies, string(body))
+	}))
+
+	_, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equalStrings(paths, []string{"/user/details"}) {
+		t.Errorf("paths = %v", paths)
+	}
+	if len(bodies) != 1 || bodies[0] != fixture(t, "user_details") {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+func TestMaxBodyLog(t *testing.T) {
+	var bodies []string
+	c, _ := newFixtureClient(t,
+		WithResponseHook(func(path string, body []byte) { bodies = append(bodies, string(body)) }),
+		WithMaxBodyLog(10),
+	)
+
+	user, err := c.GetUser("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "X" {
+		t.Errorf("user = %+v, want the full body unmarshaled", user)
+	}
+
+	username, err := c.GetUsername("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "X" {
+		t.Errorf("username = %q", username)
+	}
+
+	want := fixture(t, "user_details")[:10] + "..."
+	if len(bodies) != 2 || bodies[0] != want || len(bodies[1]) != 13 {
+		t.Errorf("bodies = %q, want truncated to 10 bytes", bodies)
+	}
+
+	if _, err := New("key", WithMaxBodyLog(0)); err == nil {
+		t.Error("WithMaxBodyLog(0) accepted")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	var infos []RequestInfo
+	c, _ := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{status: http.StatusNotFound}}},
+	},
+		WithRequestID(nil),
+		WithObserver(func(info RequestInfo) { infos = append(infos, info) }),
+	)
+
+	_, err := c.GetUser("783214")
+	if err == nil {
+		t.Fatal("GetUser succeeded")
+	}
+
+	if len(infos) != 1 {
+		t.Fatalf("observed %d requests, want 1", len(infos))
+	}
+	info := infos[0]
+	if info.Method != http.MethodGet || info.StatusCode != http.StatusNotFound || !IsNotFound(info.Err) {
+		t.Errorf("info = %+v", info)
+	}
+	if info.URL != "https://twitter154.p.rapidapi.com/user/details?user_id=783214" {
+		t.Errorf("URL = %q", info.URL)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(info.RequestID) {
+		t.Errorf("request ID %q isn't a version 4 UUID", info.RequestID)
+	}
+}
+
+func TestHookSampling(t *testing.T) {
+	const requests = 1000
+
+	sample := func(rate float64) (observed, logged int) {
+		c, _ := newFixtureClient(t,
+			WithRandSource(rand.NewSource(1)),
+			WithHookSampling(rate),
+			WithObserver(func(RequestInfo) { observed++ }),
+			WithResponseHook(func(string, []byte) { logged++ }),
+		)
+
+		for i := 0; i < requests; i++ {
+			_, err := c.GetUsername("783214")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return observed, logged
+	}
+
+	observed, logged := sample(0.25)
+	if observed != logged {
+		t.Errorf("observed %d requests but logged %d, want the same ones", observed, logged)
+	}
+	if observed < 200 || observed > 300 {
+		t.Errorf("sampled %d of %d requests at 0.25", observed, requests)
+	}
+	if again, _ := sample(0.25); again != observed {
+		t.Errorf("sampled %d requests, then %d with the same seed", observed, again)
+	}
+
+	if observed, _ := sample(0); observed != 0 {
+		t.Errorf("sampled %d requests at 0", observed)
+	}
+	if observed, _ := sample(1); observed != requests {
+		t.Errorf("sampled %d requests at 1, want all %d", observed, requests)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// QuotaInfo is the state of the API key's request quota, as reported by
+// RapidAPI in the X-RateLimit-Requests-* headers of a response.
+type QuotaInfo struct {
+	Limit     int
+	Remaining int
+	// Reset is when the quota resets.
+	Reset time.Time
+	// Observed is when the response reporting the quota was received.
+	Observed time.Time
+}
+
+// quotaState holds the quota reported by the latest response that had one.
+type quotaState struct {
+	mu   sync.Mutex
+	info QuotaInfo
+	ok   bool
+}
+
+func (q *quotaState) get() (info QuotaInfo, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.info, q.ok
+}
+
+func (q *quotaState) set(info QuotaInfo) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.info, q.ok = info, true
+}
+
+// parseQuota reads the quota headers of a response. It returns false if the
+// limit and remaining count aren't both present.
+func parseQuota(header http.Header, now time.Time) (info QuotaInfo, ok bool) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Requests-Limit"))
+	if err != nil {
+		return info, false
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Requests-Remaining"))
+	if err != nil {
+		return info, false
+	}
+
+	info = QuotaInfo{
+		Limit:     limit,
+		Remaining: remaining,
+		Observed:  now,
+	}
+
+	// The reset header is the number of seconds until the quota resets.
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Requests-Reset"), 10, 64); err == nil {
+		info.Reset = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	return info, true
+}
+
+// Usage returns the key's request quota as reported by the latest response.
+// RapidAPI has no usage endpoint, so if no response has reported the quota
+// yet, Usage makes a request with Ping, which counts against it. It fails
+// with ErrNoQuota if the response doesn't report the quota either.
+func (c *Client) Usage(ctx context.Context) (QuotaInfo, error) {
+	if info, ok := c.options.quota.get(); ok {
+		return info, nil
+	}
+
+	err := c.Ping(ctx)
+	if err != nil {
+		return QuotaInfo{}, fmt.Errorf("usage: %w", err)
+	}
+
+	info, ok := c.options.quota.get()
+	if !ok {
+		return QuotaInfo{}, fmt.Errorf("usage: %w", ErrNoQuota)
+	}
+	return info, nil
+}
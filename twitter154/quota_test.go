@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseQuota(t *testing.T) {
+	now := time.Date(2023, 9, 30, 12, 0, 0, 0, time.UTC)
+
+	header := make(http.Header)
+	header.Set("X-RateLimit-Requests-Limit", "10000")
+	header.Set("X-RateLimit-Requests-Remaining", "9876")
+	header.Set("X-RateLimit-Requests-Reset", "3600")
+
+	info, ok := parseQuota(header, now)
+	want := QuotaInfo{Limit: 10000, Remaining: 9876, Reset: now.Add(time.Hour), Observed: now}
+	if !ok || info != want {
+		t.Errorf("parseQuota = %+v, %v, want %+v", info, ok, want)
+	}
+
+	header.Del("X-RateLimit-Requests-Reset")
+	info, ok = parseQuota(header, now)
+	if !ok || !info.Reset.IsZero() {
+		t.Errorf("parseQuota without a reset = %+v, %v", info, ok)
+	}
+
+	header.Del("X-RateLimit-Requests-Remaining")
+	if _, ok := parseQuota(header, now); ok {
+		t.Error("parseQuota without the remaining count succeeded")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	c, m := newTestClient(t, []route{
+		{path: "/user/username", responses: []response{{
+			header: map[string]string{
+				"X-RateLimit-Requests-Limit":     "500",
+				"X-RateLimit-Requests-Remaining": "499",
+			},
+			body: fixture(t, "user_username"),
+		}}},
+	})
+
+	info, err := c.Usage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Limit != 500 || info.Remaining != 499 {
+		t.Errorf("Usage = %+v", info)
+	}
+
+	// The quota reported by the ping is reused.
+	_, err = c.Usage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.sent()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestUsageNotReported(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	_, err := c.Usage(context.Background())
+	if !errors.Is(err, ErrNoQuota) {
+		t.Errorf("err = %v, want ErrNoQuota", err)
+	}
+}
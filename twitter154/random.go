@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+// lockedRand makes a rand.Rand safe for concurrent use.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.r.Int63n(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.r.Float64()
+}
+
+// WithRandSource makes the client draw the random numbers behind
+// WithHookSampling and WithRandomizedRateLimit from src rather than from
+// math/rand's global source, so that a seeded source makes them
+// reproducible. The client serializes its use of src.
+func WithRandSource(src rand.Source) option {
+	return func(option *options) error {
+		if src == nil {
+			return errors.New("nil rand source")
+		}
+
+		option.rand = &lockedRand{r: rand.New(src)}
+		return nil
+	}
+}
+
+// float64 returns a random number in [0, 1) from the WithRandSource
+// source, or math/rand's global source.
+func (c *Client) float64() float64 {
+	if c.options.rand == nil {
+		return rand.Float64()
+	}
+	return c.options.rand.Float64()
+}
+
+// int63n returns a random number in [0, n) from the WithRandSource source,
+// or math/rand's global source.
+func (c *Client) int63n(n int64) int64 {
+	if c.options.rand == nil {
+		return rand.Int63n(n)
+	}
+	return c.options.rand.Int63n(n)
+}
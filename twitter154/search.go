@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SearchBuilder assembles a search step by step, as an alternative to
+// passing options to Search. Each method returns the builder, so calls can
+// be chained:
+//
+//	tweets, err := c.NewSearch().
+//		Query("golang").
+//		Section(SearchLatest).
+//		Language("en").
+//		Limit(200).
+//		Do(ctx)
+//
+// An invalid argument is reported by Do. A SearchBuilder is not safe for
+// concurrent use.
+type SearchBuilder struct {
+	c     *Client
+	query string
+	opts  []searchOption
+	limit int
+	err   error
+}
+
+// NewSearch returns an empty SearchBuilder.
+func (c *Client) NewSearch() *SearchBuilder {
+	return &SearchBuilder{c: c}
+}
+
+// Query sets the search query.
+func (b *SearchBuilder) Query(query string) *SearchBuilder {
+	b.query = query
+	return b
+}
+
+// Section is WithSection.
+func (b *SearchBuilder) Section(section SearchSection) *SearchBuilder {
+	b.opts = append(b.opts, WithSection(section))
+	return b
+}
+
+// Language is WithSearchLanguage.
+func (b *SearchBuilder) Language(code string) *SearchBuilder {
+	b.opts = append(b.opts, WithSearchLanguage(code))
+	return b
+}
+
+// DateRange is WithDateRange.
+func (b *SearchBuilder) DateRange(start, end time.Time) *SearchBuilder {
+	b.opts = append(b.opts, WithDateRange(start, end))
+	return b
+}
+
+// Geocode is WithGeocode.
+func (b *SearchBuilder) Geocode(latitude, longitude float64, radius string) *SearchBuilder {
+	b.opts = append(b.opts, WithGeocode(latitude, longitude, radius))
+	return b
+}
+
+// Option adds any other search option.
+func (b *SearchBuilder) Option(opt searchOption) *SearchBuilder {
+	b.opts = append(b.opts, opt)
+	return b
+}
+
+// Limit stops pagination once n tweets have been fetched, and caps the
+// results at n.
+func (b *SearchBuilder) Limit(n int) *SearchBuilder {
+	if n <= 0 {
+		b.err = errors.Join(b.err, fmt.Errorf("invalid limit: %d", n))
+		return b
+	}
+
+	b.limit = n
+	return b
+}
+
+// Do runs the search.
+func (b *SearchBuilder) Do(ctx context.Context) (tweets []Tweet, err error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.query == "" {
+		return nil, errors.New("empty query")
+	}
+
+	return b.c.search(ctx, b.query, b.opts, pagination[Tweet]{limit: b.limit})
+}
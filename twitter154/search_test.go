@@ -0,0 +1,359 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchParams(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	_, err := c.Search("golang",
+		WithGeocode(37.7749, -122.4194, "10km"),
+		WithSection(SearchLatest),
+		WithSearchLanguage("en"),
+		WithDateRange(time.Date(2023, 9, 1, 23, 0, 0, 0, time.UTC), time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := m.sent()[0].URL.Query()
+	want := map[string]string{
+		"query":      "golang",
+		"geocode":    "37.7749,-122.4194,10km",
+		"section":    "latest",
+		"language":   "en",
+		"start_date": "2023-09-01",
+		"end_date":   "2023-09-30",
+	}
+	for k, v := range want {
+		if got := query.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestSearchInvalidOptions(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	for _, opt := range []searchOption{
+		WithGeocode(91, 0, "10km"),
+		WithGeocode(0, 0, "10m"),
+		WithGeocode(0, 0, "0km"),
+		WithSection("oldest"),
+		WithSearchLanguage(""),
+		WithSearchLanguageFilter(),
+		WithDateRange(time.Date(2023, 9, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)),
+		WithUnfoldEmbedded(-1),
+		WithSearchUntilSeen(nil),
+		WithSearchMinFavorites(-1),
+	} {
+		if _, err := c.Search("golang", opt); err == nil {
+			t.Errorf("invalid option accepted")
+		}
+	}
+	if n := len(m.sent()); n != 0 {
+		t.Errorf("sent %d requests with invalid options", n)
+	}
+}
+
+func TestSearchUnfoldEmbedded(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	tweets, err := c.Search("golang", WithUnfoldEmbedded(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The tweet retweeted and quoted by the first two results is listed
+	// once, after the first.
+	want := []string{"1707930442378735751", "1699829335393034669", "1707928410226413793", "1707925106155696464"}
+	if ids := tweetIds(tweets); !equalStrings(ids, want) {
+		t.Errorf("tweet ids = %v, want %v", ids, want)
+	}
+
+	tweets, err = c.Search("golang", WithUnfoldEmbedded(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets) != 3 {
+		t.Errorf("got %d tweets at depth 0, want 3", len(tweets))
+	}
+}
+
+func TestSearchUnfoldEmbeddedLimit(t *testing.T) {
+	want := []string{"1707930442378735751", "1699829335393034669"}
+
+	c, _ := newFixtureClient(t)
+	tweets, err := c.NewSearch().Query("golang").Option(WithUnfoldEmbedded(1)).Limit(2).Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, want) {
+		t.Errorf("tweet ids = %v, want the limit to count unfolded tweets", ids)
+	}
+
+	c, _ = newFixtureClient(t, WithGlobalMaxResults(2))
+	tweets, err = c.Search("golang", WithUnfoldEmbedded(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, want) {
+		t.Errorf("tweet ids = %v, want WithGlobalMaxResults to count unfolded tweets", ids)
+	}
+}
+
+func TestSearchClientSideFilters(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	tweets, err := c.Search("golang", WithSearchLanguageFilter("DE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1707925106155696464"}) {
+		t.Errorf("tweet ids = %v, want the German tweet", ids)
+	}
+
+	tweets, err = c.Search("golang", WithSearchMinFavorites(10), WithSearchMinRetweets(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"1707928410226413793"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+}
+
+func TestSearchMinFavoritesLimit(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"4","favorite_count":1},
+		{"tweet_id":"3","favorite_count":10},
+		{"tweet_id":"2","favorite_count":1},
+		{"tweet_id":"1","favorite_count":10}
+	]}`
+	c, _ := newTestClient(t, []route{{path: "/search/search", responses: []response{{body: body}}}})
+
+	tweets, err := c.NewSearch().Query("golang").Option(WithSearchMinFavorites(10)).Limit(2).Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "1"}) {
+		t.Errorf("tweet ids = %v, want the limit to count only kept tweets", ids)
+	}
+}
+
+func TestSearchUntilSeen(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/search/search", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}))
+
+	tweets, err := c.Search("golang", WithSection(SearchLatest), WithSearchUntilSeen(func(t Tweet) bool {
+		return t.TweetId == "3"
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"5", "4"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestSearchSkipsMalformed(t *testing.T) {
+	body := `{"results":[
+		{"tweet_id":"3"},
+		{"tweet_id":2},
+		{"tweet_id":"1"}
+	]}`
+
+	var skipped []error
+	c, _ := newTestClient(t, []route{{path: "/search/search", responses: []response{{body: body}}}},
+		WithSkippedResultHook(func(err error) { skipped = append(skipped, err) }),
+	)
+
+	tweets, err := c.Search("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := tweetIds(tweets); !equalStrings(ids, []string{"3", "1"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("skipped = %v, want one error", skipped)
+	}
+}
+
+func TestSearchEach(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/search/search", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}))
+
+	var ids []string
+	err := c.SearchEach(context.Background(), "golang", func(t Tweet) error {
+		ids = append(ids, t.TweetId)
+		if len(ids) == 3 {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(ids, []string{"5", "4", "3"}) {
+		t.Errorf("tweet ids = %v", ids)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+
+	ids = nil
+	err = c.SearchEach(context.Background(), "golang", func(t Tweet) error {
+		ids = append(ids, t.TweetId)
+		return nil
+	}, WithSection(SearchLatest), WithSearchUntilSeen(func(t Tweet) bool { return t.TweetId == "2" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(ids, []string{"5", "4", "3"}) {
+		t.Errorf("tweet ids until seen = %v", ids)
+	}
+	if got := m.sent()[len(m.sent())-1].URL.Query().Get("section"); got != "latest" {
+		t.Errorf("section = %q, want the option forwarded", got)
+	}
+
+	if err := c.SearchEach(context.Background(), "golang", func(t Tweet) error { return nil }, WithSection("oldest")); err == nil {
+		t.Error("SearchEach with an invalid option succeeded")
+	}
+
+	errFn := errors.New("fn failed")
+	err = c.SearchEach(context.Background(), "golang", func(t Tweet) error { return errFn })
+	if !errors.Is(err, errFn) {
+		t.Errorf("err = %v, want fn's error", err)
+	}
+}
+
+func TestSearchEachFilters(t *testing.T) {
+	c, _ := newFixtureClient(t)
+
+	var ids []string
+	err := c.SearchEach(context.Background(), "golang", func(t Tweet) error {
+		ids = append(ids, t.TweetId)
+		return nil
+	}, WithSearchLanguageFilter("DE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(ids, []string{"1707925106155696464"}) {
+		t.Errorf("tweet ids = %v, want the German tweet", ids)
+	}
+
+	ids = nil
+	err = c.SearchEach(context.Background(), "golang", func(t Tweet) error {
+		ids = append(ids, t.TweetId)
+		return nil
+	}, WithUnfoldEmbedded(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1707930442378735751", "1699829335393034669", "1707928410226413793", "1707925106155696464"}; !equalStrings(ids, want) {
+		t.Errorf("tweet ids = %v, want %v", ids, want)
+	}
+}
+
+func TestSearchBuilder(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(b *SearchBuilder) *SearchBuilder
+		params map[string]string
+		count  int
+	}{
+		{
+			name:   "query",
+			build:  func(b *SearchBuilder) *SearchBuilder { return b.Query("golang") },
+			params: map[string]string{"query": "golang", "limit": "100"},
+			count:  3,
+		},
+		{
+			name: "section, language and limit",
+			build: func(b *SearchBuilder) *SearchBuilder {
+				return b.Query("golang").Section(SearchLatest).Language("en").Limit(2)
+			},
+			params: map[string]string{"section": "latest", "language": "en"},
+			count:  2,
+		},
+		{
+			name: "date range and geocode",
+			build: func(b *SearchBuilder) *SearchBuilder {
+				return b.Query("golang").
+					DateRange(time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), time.Time{}).
+					Geocode(52.52, 13.405, "5mi")
+			},
+			params: map[string]string{"start_date": "2023-09-01", "end_date": "", "geocode": "52.52,13.405,5mi"},
+			count:  3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, m := newFixtureClient(t)
+
+			tweets, err := tt.build(c.NewSearch()).Do(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tweets) != tt.count {
+				t.Errorf("got %d tweets, want %d", len(tweets), tt.count)
+			}
+
+			query := m.sent()[0].URL.Query()
+			for k, v := range tt.params {
+				if got := query.Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBuilderErrors(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	for _, b := range []*SearchBuilder{
+		c.NewSearch(),
+		c.NewSearch().Query("golang").Limit(0),
+		c.NewSearch().Query("golang").Section("oldest"),
+	} {
+		if _, err := b.Do(context.Background()); err == nil {
+			t.Error("invalid search succeeded")
+		}
+	}
+	if n := len(m.sent()); n != 0 {
+		t.Errorf("sent %d requests for invalid searches", n)
+	}
+}
+
+func TestSearchCount(t *testing.T) {
+	c, m := newTestClient(t, tweetPages("/search/search", []string{"5", "4"}, []string{"3", "2"}, []string{"1"}))
+
+	count, err := c.SearchCount("golang", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+	if n := len(m.sent()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestSearchCountErrors(t *testing.T) {
+	c, _ := newTestClient(t, []route{{path: "/search/search", responses: []response{{status: http.StatusBadRequest}}}})
+
+	if _, err := c.SearchCount("golang", 0); err == nil {
+		t.Error("SearchCount with a zero max succeeded")
+	}
+	if _, err := c.SearchCount("golang", 10); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("err = %v, want ErrBadRequest", err)
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GroupSelfThreads groups a user's timeline into threads: a tweet and the
+// chain of replies its author made to it, each replying to the one before,
+// form one thread ordered oldest first. A reply joins the thread of its
+// parent only if the parent is in tweets and has the same author, so a
+// thread broken by a missing tweet is split in two, and tweets of the same
+// conversation that don't reply to each other stay apart. Other tweets are
+// threads of their own. Threads are in the order their first tweet appears
+// in tweets, which need not be sorted.
+func GroupSelfThreads(tweets []Tweet) [][]Tweet {
+	index := make(map[string]int, len(tweets))
+	for i, t := range tweets {
+		if _, ok := index[t.TweetId]; !ok {
+			index[t.TweetId] = i
+		}
+	}
+
+	// parent is a union-find forest over tweets, joining each reply to its
+	// parent.
+	parent := make([]int, len(tweets))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i, t := range tweets {
+		if t.InReplyToStatusId == "" {
+			continue
+		}
+		j, ok := index[t.InReplyToStatusId]
+		if !ok || tweets[j].User.UserId != t.User.UserId {
+			continue
+		}
+
+		// Keep the root the earlier of the two, so a thread is placed by
+		// its first tweet in tweets.
+		ri, rj := find(i), find(j)
+		if ri < rj {
+			parent[rj] = ri
+		} else {
+			parent[ri] = rj
+		}
+	}
+
+	threads := [][]Tweet{}
+	thread := make(map[int]int)
+	for i, t := range tweets {
+		root := find(i)
+		n, ok := thread[root]
+		if !ok {
+			n = len(threads)
+			thread[root] = n
+			threads = append(threads, nil)
+		}
+		threads[n] = append(threads[n], t)
+	}
+
+	for _, thread := range threads {
+		sort.SliceStable(thread, func(i, j int) bool {
+			return thread[i].Timestamp < thread[j].Timestamp
+		})
+	}
+
+	return threads
+}
+
+// _urlLength is the length Twitter counts for any URL, after t.co wrapping.
+const _urlLength = 23
+
+var _urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+`)
+
+// _urlTrailing holds the punctuation that ends a sentence or clause rather
+// than a URL when it is the last character of a match.
+const _urlTrailing = `.,:;!?'"`
+
+// WeightedLength returns the length of the tweet's text as Twitter counts
+// it against the character limit: every URL counts as 23, an emoji,
+// including a sequence joined into one, counts as 2, characters from the
+// Latin, Greek, Cyrillic and similar blocks and general punctuation count
+// as 1, and all others, such as CJK, count as 2.
+func (t Tweet) WeightedLength() int {
+	length := 0
+	last := 0
+	for _, loc := range _urlPattern.FindAllStringIndex(t.Text, -1) {
+		end := urlEnd(t.Text[loc[0]:loc[1]])
+		length += weightedLength(t.Text[last:loc[0]]) + _urlLength
+		last = loc[0] + end
+	}
+	return length + weightedLength(t.Text[last:])
+}
+
+// urlEnd returns the length of the URL at the start of match, leaving out
+// trailing punctuation, and a closing parenthesis without an opening one
+// in the URL, as in "(see https://go.dev)".
+func urlEnd(match string) int {
+	end := len(match)
+	for end > 0 {
+		c := match[end-1]
+		if strings.IndexByte(_urlTrailing, c) < 0 &&
+			!(c == ')' && strings.Count(match[:end], ")") > strings.Count(match[:end], "(")) {
+			break
+		}
+		end--
+	}
+	return end
+}
+
+func weightedLength(s string) int {
+	runes := []rune(s)
+	length := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if n := keycapLength(runes[i:]); n > 0 {
+			length += 2
+			i += n - 1
+			continue
+		}
+
+		if !isEmoji(r) {
+			length += runeWeight(r)
+			continue
+		}
+
+		length += 2
+		if isRegionalIndicator(r) && i+1 < len(runes) && isRegionalIndicator(runes[i+1]) {
+			i++
+			continue
+		}
+
+		// Skip the modifiers and joined emoji that render as part of r.
+		for i+1 < len(runes) {
+			next := runes[i+1]
+			if isEmojiModifier(next) {
+				i++
+			} else if next == '\u200d' && i+2 < len(runes) {
+				i += 2
+			} else {
+				break
+			}
+		}
+	}
+	return length
+}
+
+// runeWeight returns the weight of a non-emoji rune per Twitter's counting
+// rules.
+func runeWeight(r rune) int {
+	switch {
+	case r <= 0x10ff,
+		r >= 0x2000 && r <= 0x200d,
+		r >= 0x2010 && r <= 0x201f,
+		r >= 0x2032 && r <= 0x2037:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// keycapLength returns the number of runes of the keycap emoji at the
+// start of runes, or 0 if there is none. A keycap is a digit, '#' or '*',
+// an optional U+FE0F variation selector and U+20E3, the combining
+// enclosing keycap.
+func keycapLength(runes []rune) int {
+	if len(runes) < 2 || !(runes[0] >= '0' && runes[0] <= '9' || runes[0] == '#' || runes[0] == '*') {
+		return 0
+	}
+
+	n := 1
+	if runes[n] == '\ufe0f' {
+		n++
+	}
+	if n < len(runes) && runes[n] == '\u20e3' {
+		return n + 1
+	}
+	return 0
+}
+
+func isEmoji(r rune) bool {
+	return r >= 0x1f000 && r <= 0x1faff || r >= 0x2600 && r <= 0x27bf
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isEmojiModifier(r rune) bool {
+	return r == '\ufe0f' || r >= 0x1f3fb && r <= 0x1f3ff || r >= 0xe0020 && r <= 0xe007f
+}
+
+const (
+	// _snowflakeEpoch is the Unix time in milliseconds that Twitter's
+	// snowflake IDs count from.
+	_snowflakeEpoch = 1288834974657
+	// _snowflakeTimeShift is the number of low bits of a snowflake ID below
+	// its timestamp.
+	_snowflakeTimeShift = 22
+	// _minSnowflakeId bounds the IDs from before snowflakes were introduced
+	// in November 2010, which were assigned sequentially and carry no time.
+	_minSnowflakeId = 30000000000
+)
+
+// TweetIDTime returns the creation time encoded in a tweet ID, to the
+// millisecond, without a request. It fails for IDs that aren't numeric and
+// for IDs from before November 2010, which don't encode a time.
+func TweetIDTime(id string) (time.Time, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid tweet id %q", id)
+	}
+	if n < _minSnowflakeId {
+		return time.Time{}, fmt.Errorf("tweet id %s predates snowflake ids", id)
+	}
+
+	ms := int64(n>>_snowflakeTimeShift) + _snowflakeEpoch
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+// mediaVariant is the type of Media.VideoInfo.Variants' elements.
+type mediaVariant = struct {
+	Bitrate     int    `json:"bitrate,omitempty"`
+	ContentType string `json:"content_type"`
+	Url         string `json:"url"`
+}
+
+// MergeMedia returns t with its media fields filled in from each other, as
+// endpoints fill in different ones: GetUserTweets tends to set MediaUrl and
+// VideoUrl, GetTweetDetails ExtendedEntities. Only empty fields are filled:
+//
+//   - an empty MediaUrl gets the media_url_https of each of the extended
+//     entities' photos;
+//   - an empty VideoUrl gets the variants of each of the extended entities'
+//     videos and GIFs;
+//   - empty extended entities get a photo for each MediaUrl and, if
+//     VideoUrl is set, a video with its variants.
+//
+// The retweeted and quoted tweets are merged too.
+func (t Tweet) MergeMedia() Tweet {
+	entities := t.ExtendedEntities.Media
+
+	if len(t.MediaUrl) == 0 {
+		for _, m := range entities {
+			if m.Type == MediaTypePhoto && m.MediaUrlHttps != "" {
+				t.MediaUrl = append(t.MediaUrl, m.MediaUrlHttps)
+			}
+		}
+	}
+
+	if len(t.VideoUrl) == 0 {
+		for _, m := range entities {
+			for _, v := range m.VideoInfo.Variants {
+				t.VideoUrl = append(t.VideoUrl, VideoUrl(v))
+			}
+		}
+	}
+
+	if len(entities) == 0 {
+		var media []Media
+		for _, url := range t.MediaUrl {
+			media = append(media, Media{
+				Type:          MediaTypePhoto,
+				MediaUrlHttps: url,
+			})
+		}
+		if len(t.VideoUrl) > 0 {
+			video := Media{Type: MediaTypeVideo}
+			for _, v := range t.VideoUrl {
+				video.VideoInfo.Variants = append(video.VideoInfo.Variants, mediaVariant(v))
+			}
+			media = append(media, video)
+		}
+		t.ExtendedEntities.Media = media
+	}
+
+	if t.RetweetStatus != nil {
+		retweeted := t.RetweetStatus.MergeMedia()
+		t.RetweetStatus = &retweeted
+	}
+	if t.QuotedStatus != nil {
+		quoted := t.QuotedStatus.MergeMedia()
+		t.QuotedStatus = &quoted
+	}
+
+	return t
+}
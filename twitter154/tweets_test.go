@@ -0,0 +1,175 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTweetIDTime(t *testing.T) {
+	tests := []struct {
+		id   string
+		want time.Time
+	}{
+		{"1212092628029698048", time.Date(2019, 12, 31, 19, 26, 16, 771e6, time.UTC)},
+		// 2023-01-01T00:00:00Z, with the worker and sequence bits set.
+		{"1609338612741046272", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"1609338612745240575", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := TweetIDTime(tt.id)
+		if err != nil {
+			t.Errorf("TweetIDTime(%s): %v", tt.id, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("TweetIDTime(%s) = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+
+	for _, id := range []string{"", "abc", "-1", "20", "29999999999", "18446744073709551616"} {
+		if got, err := TweetIDTime(id); err == nil {
+			t.Errorf("TweetIDTime(%q) = %s, want an error", id, got)
+		}
+	}
+}
+
+func TestMergeMedia(t *testing.T) {
+	video := VideoUrl{Bitrate: 832000, ContentType: "video/mp4", Url: "https://video.twimg.com/a.mp4"}
+
+	// A timeline tweet, with only MediaUrl and VideoUrl.
+	timeline := Tweet{
+		MediaUrl: []string{"https://pbs.twimg.com/media/a.jpg"},
+		VideoUrl: []VideoUrl{video},
+	}.MergeMedia()
+
+	media := timeline.ExtendedEntities.Media
+	if len(media) != 2 || media[0].Type != MediaTypePhoto || media[0].MediaUrlHttps != "https://pbs.twimg.com/media/a.jpg" {
+		t.Fatalf("extended entities = %+v", media)
+	}
+	if media[1].Type != MediaTypeVideo || len(media[1].VideoInfo.Variants) != 1 || media[1].VideoInfo.Variants[0].Url != video.Url {
+		t.Errorf("video entity = %+v", media[1])
+	}
+
+	// A details tweet, with only extended entities.
+	details := Tweet{ExtendedEntities: timeline.ExtendedEntities}.MergeMedia()
+	if !equalStrings(details.MediaUrl, []string{"https://pbs.twimg.com/media/a.jpg"}) {
+		t.Errorf("MediaUrl = %v", details.MediaUrl)
+	}
+	if len(details.VideoUrl) != 1 || details.VideoUrl[0] != video {
+		t.Errorf("VideoUrl = %v", details.VideoUrl)
+	}
+
+	// Fields already set are left alone.
+	both := Tweet{
+		MediaUrl:         []string{"https://pbs.twimg.com/media/b.jpg"},
+		ExtendedEntities: timeline.ExtendedEntities,
+	}.MergeMedia()
+	if !equalStrings(both.MediaUrl, []string{"https://pbs.twimg.com/media/b.jpg"}) || len(both.ExtendedEntities.Media) != 2 {
+		t.Errorf("merged tweet = %+v", both)
+	}
+
+	// Embedded tweets are merged too.
+	retweet := Tweet{RetweetStatus: &Tweet{MediaUrl: []string{"https://pbs.twimg.com/media/c.jpg"}}}.MergeMedia()
+	if len(retweet.RetweetStatus.ExtendedEntities.Media) != 1 {
+		t.Errorf("retweeted tweet's extended entities = %+v", retweet.RetweetStatus.ExtendedEntities)
+	}
+
+	if got := (Tweet{}).MergeMedia(); got.HasMedia() {
+		t.Errorf("MergeMedia of a tweet without media = %+v", got)
+	}
+}
+
+func TestWithMergedMedia(t *testing.T) {
+	c, _ := newFixtureClient(t, WithMergedMedia())
+
+	tweets, err := c.GetUserTweets("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets[0].ExtendedEntities.Media) != 1 || len(tweets[2].ExtendedEntities.Media) != 1 {
+		t.Errorf("extended entities = %+v, %+v", tweets[0].ExtendedEntities, tweets[2].ExtendedEntities)
+	}
+
+	media, err := c.GetUserMedia("783214")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media[0].MediaUrl) != 1 || len(media[1].VideoUrl) != 3 {
+		t.Errorf("MediaUrl, VideoUrl = %v, %v", media[0].MediaUrl, media[1].VideoUrl)
+	}
+}
+
+func TestGroupSelfThreads(t *testing.T) {
+	tweet := func(id, user, replyTo string, timestamp int64) Tweet {
+		return Tweet{
+			TweetId:           id,
+			ConversationId:    "1",
+			InReplyToStatusId: replyTo,
+			User:              User{UserId: user},
+			Timestamp:         timestamp,
+		}
+	}
+
+	tweets := []Tweet{
+		// Newest first, as a timeline is.
+		tweet("5", "a", "4", 500),
+		tweet("4", "a", "2", 400),
+		// A reply by a to b, in the same conversation, is its own thread.
+		tweet("3", "a", "6", 300),
+		tweet("2", "a", "1", 200),
+		tweet("1", "a", "", 100),
+		tweet("6", "b", "1", 150),
+		// A reply whose parent is missing starts a thread.
+		tweet("8", "a", "7", 800),
+		tweet("9", "a", "8", 900),
+	}
+
+	var got [][]string
+	for _, thread := range GroupSelfThreads(tweets) {
+		got = append(got, tweetIds(thread))
+	}
+
+	want := [][]string{{"1", "2", "4", "5"}, {"3"}, {"6"}, {"8", "9"}}
+	if len(got) != len(want) {
+		t.Fatalf("threads = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !equalStrings(got[i], want[i]) {
+			t.Errorf("threads = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"héllo", 5},
+		{"日本語", 6},
+		{"Go 言語", 7},
+
+		{"https://go.dev", 23},
+		{"see https://a.com, ok", 31},
+		{"go.dev www.example.com/x.", 31},
+		{"(see https://go.dev)", 29},
+		{"https://en.wikipedia.org/wiki/Go_(language)", 23},
+		{"https://a.com/1 https://b.com/2", 47},
+
+		{"\U0001f600", 2},
+		{"\u2764\ufe0f", 2},
+		{"\U0001f44d\U0001f3fd", 2},
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 2},
+		{"\U0001f1ef\U0001f1f5", 2},
+		{"1\ufe0f\u20e3", 2},
+		{"#\u20e3 1", 4},
+		{"1 2", 3},
+	}
+	for _, tt := range tests {
+		if got := (Tweet{Text: tt.text}).WeightedLength(); got != tt.want {
+			t.Errorf("WeightedLength(%+q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -1,13 +1,49 @@
 package api
 
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Count is an integer count field. Some plans format counts as strings, with
+// or without thousands separators, so besides JSON numbers it accepts
+// strings like "1234" and "1,234".
+type Count int64
+
+func (c *Count) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		data = []byte(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+		if len(data) == 0 {
+			*c = 0
+			return nil
+		}
+	}
+
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid count %s", data)
+	}
+
+	*c = Count(n)
+	return nil
+}
+
 type User struct {
 	CreationDate     string        `json:"creation_date"`
 	UserId           string        `json:"user_id"`
 	Username         string        `json:"username"`
 	Name             string        `json:"name"`
-	FollowerCount    int           `json:"follower_count"`
-	FollowingCount   int           `json:"following_count"`
-	FavouritesCount  int           `json:"favourites_count"`
+	FollowerCount    Count         `json:"follower_count"`
+	FollowingCount   Count         `json:"following_count"`
+	FavouritesCount  Count         `json:"favourites_count"`
 	IsPrivate        bool          `json:"is_private"`
 	IsVerified       bool          `json:"is_verified"`
 	IsBlueVerified   bool          `json:"is_blue_verified"`
@@ -16,13 +52,36 @@ type User struct {
 	ProfileBannerUrl string        `json:"profile_banner_url"`
 	Description      string        `json:"description"`
 	ExternalUrl      string        `json:"external_url"`
-	NumberOfTweets   int           `json:"number_of_tweets"`
+	NumberOfTweets   Count         `json:"number_of_tweets"`
 	Bot              bool          `json:"bot"`
 	Timestamp        int           `json:"timestamp"`
 	HasNftAvatar     bool          `json:"has_nft_avatar"`
 	Category         *UserCategory `json:"category"`
 	DefaultProfile   bool          `json:"default_profile"`
 	DefaultImage     bool          `json:"default_profile_image"`
+	Affiliation      Affiliation   `json:"affiliates_highlighted_label"`
+}
+
+// Likes returns the number of tweets the user has liked, which the API
+// calls favourites_count.
+func (u User) Likes() int {
+	return int(u.FavouritesCount)
+}
+
+// BannerURL returns the URL of the user's profile banner at the given size,
+// such as "1500x500", "600x200" or "mobile_retina". An empty size returns the
+// URL as sent by the API. It returns "" if the user has no banner.
+func (u User) BannerURL(size string) string {
+	if u.ProfileBannerUrl == "" || size == "" {
+		return u.ProfileBannerUrl
+	}
+	return strings.TrimSuffix(u.ProfileBannerUrl, "/") + "/" + size
+}
+
+// IsAffiliated reports whether the user carries an affiliate badge of a
+// verified organization.
+func (u User) IsAffiliated() bool {
+	return u.Affiliation.Label.Description != ""
 }
 
 type UserCategory struct {
@@ -30,6 +89,24 @@ type UserCategory struct {
 	Id   int    `json:"id"`
 }
 
+// Affiliation is the affiliate badge of a verified organization shown next
+// to a user's name. It is empty for users without one.
+type Affiliation struct {
+	Label struct {
+		// Description is the name of the organization.
+		Description string `json:"description"`
+		Url         struct {
+			Url     string `json:"url"`
+			UrlType string `json:"urlType"`
+		} `json:"url"`
+		Badge struct {
+			Url string `json:"url"`
+		} `json:"badge"`
+		UserLabelType        string `json:"userLabelType"`
+		UserLabelDisplayType string `json:"userLabelDisplayType"`
+	} `json:"label"`
+}
+
 type Tweet struct {
 	TweetId           string           `json:"tweet_id"`
 	CreationDate      string           `json:"creation_date"`
@@ -38,14 +115,14 @@ type Tweet struct {
 	VideoUrl          []VideoUrl       `json:"video_url"`
 	User              User             `json:"user"`
 	Language          string           `json:"language"`
-	FavoriteCount     int              `json:"favorite_count"`
-	RetweetCount      int              `json:"retweet_count"`
-	ReplyCount        int              `json:"reply_count"`
-	QuoteCount        int              `json:"quote_count"`
+	FavoriteCount     Count            `json:"favorite_count"`
+	RetweetCount      Count            `json:"retweet_count"`
+	ReplyCount        Count            `json:"reply_count"`
+	QuoteCount        Count            `json:"quote_count"`
 	Retweet           bool             `json:"retweet"`
-	Views             int64            `json:"views"`
+	Views             Count            `json:"views"`
 	Timestamp         int64            `json:"timestamp"`
-	VideoViewCount    int64            `json:"video_view_count"`
+	VideoViewCount    Count            `json:"video_view_count"`
 	InReplyToStatusId string           `json:"in_reply_to_status_id"`
 	QuotedStatusId    string           `json:"quoted_status_id"`
 	BindingValues     []BindingValue   `json:"binding_values"`
@@ -54,6 +131,239 @@ type Tweet struct {
 	ConversationId    string           `json:"conversation_id"`
 	RetweetTweetId    string           `json:"retweet_tweet_id"`
 	RetweetStatus     *Tweet           `json:"retweet_status"`
+	QuotedStatus      *Tweet           `json:"quoted_status"`
+	NoteTweet         NoteTweet        `json:"note_tweet"`
+	Source            string           `json:"source"`
+	PossiblySensitive *bool            `json:"possibly_sensitive"`
+
+	// InReplyToUserId is the ID of the author of the tweet replied to, or
+	// nil if the tweet isn't a reply or the response doesn't say. It is
+	// decoded from in_reply_to_user_id by UnmarshalJSON.
+	InReplyToUserId *string `json:"-"`
+
+	// SelectedMediaUrl is the URL of the media variant chosen by the
+	// WithMediaSelection or WithTimelineMediaSelection option, if any.
+	SelectedMediaUrl string `json:"-"`
+
+	// LikelyPinned is a guess, made by GetUserTweets and GetUserTweetsPage
+	// when IncludePinned is given, that the tweet is the user's pinned
+	// tweet. The API's tweets carry no pinned marker; the pin is only put
+	// first, ahead of newer tweets, so the first tweet is guessed pinned
+	// if it is older than the second. A pinned tweet newer than the rest
+	// of the timeline isn't detected.
+	LikelyPinned bool `json:"-"`
+}
+
+// UnmarshalJSON decodes a tweet. in_reply_to_user_id is accepted as a
+// string or a number, since the ID has been sent as both.
+func (t *Tweet) UnmarshalJSON(data []byte) error {
+	type tweet Tweet
+	var r struct {
+		*tweet
+		InReplyToUserId json.RawMessage `json:"in_reply_to_user_id"`
+	}
+	r.tweet = (*tweet)(t)
+
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+
+	t.InReplyToUserId = nil
+	if len(r.InReplyToUserId) == 0 || string(r.InReplyToUserId) == "null" {
+		return nil
+	}
+
+	var id string
+	if json.Unmarshal(r.InReplyToUserId, &id) != nil {
+		var n json.Number
+		if json.Unmarshal(r.InReplyToUserId, &n) != nil {
+			return fmt.Errorf("invalid in_reply_to_user_id %s", r.InReplyToUserId)
+		}
+		id = n.String()
+	}
+	if id != "" {
+		t.InReplyToUserId = &id
+	}
+	return nil
+}
+
+// IsSelfReply reports whether the tweet replies to a tweet by its own
+// author, as the tweets of a thread do.
+func (t Tweet) IsSelfReply() bool {
+	return t.InReplyToUserId != nil && *t.InReplyToUserId == t.User.UserId
+}
+
+// Likes returns the number of times the tweet has been liked, which the API
+// calls favorite_count.
+func (t Tweet) Likes() int {
+	return int(t.FavoriteCount)
+}
+
+// TotalEngagements returns the sum of the tweet's likes, retweets, replies
+// and quotes.
+func (t Tweet) TotalEngagements() int {
+	return int(t.FavoriteCount + t.RetweetCount + t.ReplyCount + t.QuoteCount)
+}
+
+// EngagementRate returns TotalEngagements per view. A tweet without a view
+// count is treated as having one view, so the rate is never NaN or
+// infinite.
+func (t Tweet) EngagementRate() float64 {
+	views := t.Views
+	if views < 1 {
+		views = 1
+	}
+	return float64(t.TotalEngagements()) / float64(views)
+}
+
+// DisplayTweet returns the tweet a client would render: the retweeted tweet
+// if t is a retweet, otherwise t itself.
+func (t Tweet) DisplayTweet() Tweet {
+	if t.RetweetStatus != nil {
+		return *t.RetweetStatus
+	}
+	return t
+}
+
+// FullText returns the complete text of a long-form note tweet, whose Text
+// is truncated, and Text for any other tweet.
+func (t Tweet) FullText() string {
+	if t.NoteTweet.Text != "" {
+		return t.NoteTweet.Text
+	}
+	return t.Text
+}
+
+// SourceName returns the name of the app the tweet was posted from, such as
+// "Twitter for iPhone". Source is usually an HTML link to the app, whose text
+// is returned; any other Source is returned as is.
+func (t Tweet) SourceName() string {
+	source := t.Source
+	if start := strings.Index(source, ">"); strings.HasPrefix(source, "<a") && start >= 0 {
+		source = source[start+1:]
+		if end := strings.Index(source, "</a>"); end >= 0 {
+			source = source[:end]
+		}
+	}
+	return html.UnescapeString(strings.TrimSpace(source))
+}
+
+// IsSensitive reports whether the tweet is flagged as possibly sensitive.
+// A tweet whose response doesn't say is treated as not sensitive; check
+// PossiblySensitive for nil to tell the two apart.
+func (t Tweet) IsSensitive() bool {
+	return t.PossiblySensitive != nil && *t.PossiblySensitive
+}
+
+// HasMedia reports whether the tweet has any photos or videos attached.
+func (t Tweet) HasMedia() bool {
+	return len(t.ExtendedEntities.Media) > 0 || len(t.MediaUrl) > 0 || len(t.VideoUrl) > 0
+}
+
+// MediaAltTexts returns the alt text of each of the tweet's media that has
+// one, in media order.
+func (t Tweet) MediaAltTexts() []string {
+	altTexts := []string{}
+	for _, m := range t.ExtendedEntities.Media {
+		if m.AltText != "" {
+			altTexts = append(altTexts, m.AltText)
+		}
+	}
+	return altTexts
+}
+
+// HasVideo reports whether the tweet has any video URLs.
+func (t Tweet) HasVideo() bool {
+	return len(t.VideoUrl) > 0
+}
+
+// BestVideoUrl returns the highest-bitrate mp4 in t.VideoUrl. It returns
+// false if there is no mp4 variant.
+func (t Tweet) BestVideoUrl() (best VideoUrl, ok bool) {
+	for _, v := range t.VideoUrl {
+		if v.ContentType != "video/mp4" {
+			continue
+		}
+		if !ok || v.Bitrate > best.Bitrate {
+			best, ok = v, true
+		}
+	}
+	return best, ok
+}
+
+// NoteTweet is the full content of a long-form tweet. It is decoded from
+// either an object with a "text" field, as Twitter sends it, or a bare
+// string.
+type NoteTweet struct {
+	Text string `json:"text"`
+}
+
+func (n *NoteTweet) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.Text); err == nil {
+		return nil
+	}
+
+	var r struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("invalid note tweet %s", data)
+	}
+
+	n.Text = r.Text
+	return nil
+}
+
+// ImageUrl returns the URL of the tweet's first photo at the given size,
+// one of "thumb", "small", "medium", "large" and "orig". If the tweet has
+// no extended entities, the first of t.MediaUrl is returned as is. It
+// returns false if the tweet has no photo.
+func (t Tweet) ImageUrl(size string) (url string, ok bool) {
+	for _, m := range t.ExtendedEntities.Media {
+		if m.Type == MediaTypePhoto && m.MediaUrlHttps != "" {
+			return m.MediaUrlHttps + "?name=" + size, true
+		}
+	}
+
+	if len(t.ExtendedEntities.Media) == 0 && len(t.MediaUrl) > 0 {
+		return t.MediaUrl[0], true
+	}
+	return "", false
+}
+
+// MediaSelection chooses one variant of a tweet's media.
+type MediaSelection int
+
+const (
+	// SelectBestVideo chooses the highest-bitrate mp4, as BestVideoUrl.
+	SelectBestVideo MediaSelection = iota + 1
+	// SelectLargestImage chooses the first photo at its original size.
+	SelectLargestImage
+	// SelectThumbnail chooses the first photo at thumbnail size.
+	SelectThumbnail
+)
+
+// selectMedia returns the URL of the media variant chosen by sel, or "" if
+// the tweet has no such media.
+func (t Tweet) selectMedia(sel MediaSelection) string {
+	switch sel {
+	case SelectBestVideo:
+		best, _ := t.BestVideoUrl()
+		return best.Url
+	case SelectLargestImage:
+		url, _ := t.ImageUrl("orig")
+		return url
+	case SelectThumbnail:
+		url, _ := t.ImageUrl("thumb")
+		return url
+	default:
+		return ""
+	}
 }
 
 type VideoUrl struct {
@@ -66,15 +376,24 @@ type ExtendedEntities struct {
 	Media []Media `json:"media"`
 }
 
+type MediaType string
+
+const (
+	MediaTypePhoto       = MediaType("photo")
+	MediaTypeVideo       = MediaType("video")
+	MediaTypeAnimatedGif = MediaType("animated_gif")
+)
+
 type Media struct {
-	DisplayUrl          string `json:"display_url"`
-	ExpandedUrl         string `json:"expanded_url"`
-	IdStr               string `json:"id_str"`
-	Indices             []int  `json:"indices"`
-	MediaKey            string `json:"media_key"`
-	MediaUrlHttps       string `json:"media_url_https"`
-	Type                string `json:"type"`
-	Url                 string `json:"url"`
+	DisplayUrl          string    `json:"display_url"`
+	ExpandedUrl         string    `json:"expanded_url"`
+	IdStr               string    `json:"id_str"`
+	Indices             []int     `json:"indices"`
+	MediaKey            string    `json:"media_key"`
+	MediaUrlHttps       string    `json:"media_url_https"`
+	Type                MediaType `json:"type"`
+	Url                 string    `json:"url"`
+	AltText             string    `json:"ext_alt_text"`
 	AdditionalMediaInfo struct {
 		Monetizable bool `json:"monetizable"`
 	} `json:"additional_media_info"`
@@ -127,3 +446,47 @@ type BindingValue struct {
 	Key   string `json:"key"`
 	Value any    `json:"value"`
 }
+
+// _cardImageKeys are the binding value keys holding a card's preview image,
+// largest first.
+var _cardImageKeys = []string{
+	"thumbnail_image_original",
+	"photo_image_full_size_original",
+	"summary_photo_image_original",
+	"thumbnail_image_large",
+	"photo_image_full_size_large",
+	"summary_photo_image_large",
+	"thumbnail_image",
+	"summary_photo_image",
+	"thumbnail_image_small",
+}
+
+// CardImage returns the preview image of the tweet's link card, read from
+// the image_value of its binding values, preferring the largest size. It
+// returns false if the tweet has no card or the card has no image.
+func (t Tweet) CardImage() (url string, width, height int, ok bool) {
+	images := make(map[string]map[string]any, len(t.BindingValues))
+	for _, b := range t.BindingValues {
+		value, _ := b.Value.(map[string]any)
+		if image, isImage := value["image_value"].(map[string]any); isImage {
+			images[b.Key] = image
+		}
+	}
+
+	for _, key := range _cardImageKeys {
+		image, found := images[key]
+		if !found {
+			continue
+		}
+
+		url, _ = image["url"].(string)
+		if url == "" {
+			continue
+		}
+		w, _ := image["width"].(float64)
+		h, _ := image["height"].(float64)
+		return url, int(w), int(h), true
+	}
+
+	return "", 0, 0, false
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func unmarshalTweet(t *testing.T, data string) Tweet {
+	t.Helper()
+
+	var tweet Tweet
+	err := json.Unmarshal([]byte(data), &tweet)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return tweet
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		data string
+		want Count
+	}{
+		{`1234`, 1234},
+		{`"1234"`, 1234},
+		{`"1,234,567"`, 1234567},
+		{`" 42 "`, 42},
+		{`""`, 0},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		var c Count
+		err := json.Unmarshal([]byte(tt.data), &c)
+		if err != nil {
+			t.Errorf("unmarshal %s: %v", tt.data, err)
+			continue
+		}
+		if c != tt.want {
+			t.Errorf("unmarshal %s = %d, want %d", tt.data, c, tt.want)
+		}
+	}
+
+	for _, data := range []string{`"1.5k"`, `true`, `1.5`} {
+		var c Count
+		if err := json.Unmarshal([]byte(data), &c); err == nil {
+			t.Errorf("unmarshal %s = %d, want an error", data, c)
+		}
+	}
+}
+
+func TestLikes(t *testing.T) {
+	user := User{FavouritesCount: 5918}
+	if user.Likes() != 5918 {
+		t.Errorf("User.Likes = %d", user.Likes())
+	}
+
+	tweet := unmarshalTweet(t, `{"favorite_count":"1,024"}`)
+	if tweet.Likes() != 1024 {
+		t.Errorf("Tweet.Likes = %d", tweet.Likes())
+	}
+}
+
+func TestDisplayTweet(t *testing.T) {
+	tweets := make(map[string]Tweet)
+	for _, tweet := range mustSearchFixture(t) {
+		tweets[tweet.TweetId] = tweet
+	}
+
+	retweet := tweets["1707930442378735751"]
+	if got := retweet.DisplayTweet(); got.TweetId != "1699829335393034669" || got.User.Username != "golang" {
+		t.Errorf("DisplayTweet of a retweet = %+v, want the retweeted tweet", got)
+	}
+
+	quote := tweets["1707928410226413793"]
+	if got := quote.DisplayTweet(); got.TweetId != quote.TweetId {
+		t.Errorf("DisplayTweet of a quote = %s, want the tweet itself", got.TweetId)
+	}
+}
+
+// mustSearchFixture returns the tweets of the search fixture.
+func mustSearchFixture(t *testing.T) []Tweet {
+	t.Helper()
+
+	var r getSearchResponse
+	err := json.Unmarshal([]byte(fixture(t, "search_search")), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Result()
+}
+
+func TestBestVideoUrl(t *testing.T) {
+	var r getUserTweetsResponse
+	err := json.Unmarshal([]byte(fixture(t, "user_tweets_continuation")), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best, ok := r.Result()[0].BestVideoUrl()
+	if !ok || best.Bitrate != 2176000 || best.ContentType != "video/mp4" {
+		t.Errorf("BestVideoUrl = %+v, %v", best, ok)
+	}
+	if !r.Result()[0].HasVideo() {
+		t.Error("HasVideo = false")
+	}
+
+	_, ok = Tweet{}.BestVideoUrl()
+	if ok {
+		t.Error("BestVideoUrl of a tweet without video succeeded")
+	}
+
+	_, ok = Tweet{VideoUrl: []VideoUrl{{ContentType: "application/x-mpegURL", Url: "a.m3u8"}}}.BestVideoUrl()
+	if ok {
+		t.Error("BestVideoUrl without an mp4 variant succeeded")
+	}
+}
+
+func TestImageUrl(t *testing.T) {
+	var r getUserMediaResponse
+	err := json.Unmarshal([]byte(fixture(t, "user_medias")), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, ok := r.Result()[0].ImageUrl("small")
+	if !ok || url != "https://pbs.twimg.com/media/F7L8qZrWgAA2Vjy.jpg?name=small" {
+		t.Errorf("ImageUrl = %q, %v", url, ok)
+	}
+	if alts := r.Result()[0].MediaAltTexts(); !equalStrings(alts, []string{"The X app running on a TV"}) {
+		t.Errorf("MediaAltTexts = %q", alts)
+	}
+
+	_, ok = r.Result()[1].ImageUrl("small")
+	if ok {
+		t.Error("ImageUrl of a tweet with only videos succeeded")
+	}
+
+	url, ok = Tweet{MediaUrl: []string{"https://pbs.twimg.com/media/a.jpg"}}.ImageUrl("small")
+	if !ok || url != "https://pbs.twimg.com/media/a.jpg" {
+		t.Errorf("ImageUrl from MediaUrl = %q, %v", url, ok)
+	}
+}
+
+func TestBannerURL(t *testing.T) {
+	var user User
+	err := json.Unmarshal([]byte(fixture(t, "user_details")), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := user.BannerURL("1500x500"); got != "https://pbs.twimg.com/profile_banners/783214/1690175171/1500x500" {
+		t.Errorf("BannerURL = %q", got)
+	}
+	if got := user.BannerURL(""); got != user.ProfileBannerUrl {
+		t.Errorf("BannerURL(\"\") = %q", got)
+	}
+
+	var noBanner User
+	err = json.Unmarshal([]byte(`{"profile_banner_url":null}`), &noBanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := noBanner.BannerURL("1500x500"); got != "" {
+		t.Errorf("BannerURL without a banner = %q", got)
+	}
+}
+
+func TestAffiliation(t *testing.T) {
+	var user User
+	err := json.Unmarshal([]byte(`{
+		"user_id": "44196397",
+		"affiliates_highlighted_label": {
+			"label": {
+				"url": {"url": "https://twitter.com/X", "urlType": "DeepLink"},
+				"badge": {"url": "https://pbs.twimg.com/profile_images/1683899100922511378/5lY42eHs_bigger.jpg"},
+				"description": "X",
+				"userLabelType": "BusinessLabel",
+				"userLabelDisplayType": "Badge"
+			}
+		}
+	}`), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.IsAffiliated() || user.Affiliation.Label.Description != "X" || user.Affiliation.Label.Url.Url != "https://twitter.com/X" {
+		t.Errorf("affiliation = %+v", user.Affiliation)
+	}
+
+	var unaffiliated User
+	err = json.Unmarshal([]byte(fixture(t, "user_details")), &unaffiliated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unaffiliated.IsAffiliated() {
+		t.Error("user without a label is affiliated")
+	}
+}
+
+func TestNoteTweet(t *testing.T) {
+	tweet := unmarshalTweet(t, fixture(t, "tweet_details"))
+	if got := tweet.FullText(); got != "We're rolling out longer posts to more people. Here's everything you need to know about writing posts of up to 25,000 characters, and how to format them with bold and italics." {
+		t.Errorf("FullText = %q", got)
+	}
+
+	tweet = unmarshalTweet(t, `{"text":"short…","note_tweet":"long text"}`)
+	if got := tweet.FullText(); got != "long text" {
+		t.Errorf("FullText of a string note = %q", got)
+	}
+
+	tweet = unmarshalTweet(t, `{"text":"short","note_tweet":null}`)
+	if got := tweet.FullText(); got != "short" {
+		t.Errorf("FullText without a note = %q", got)
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`<a href="https://mobile.twitter.com" rel="nofollow">Twitter Web App</a>`, "Twitter Web App"},
+		{`<a href="http://twitter.com/download/iphone" rel="nofollow">Twitter for iPhone</a>`, "Twitter for iPhone"},
+		{`<a href="https://example.com">Tom &amp; Jerry</a>`, "Tom & Jerry"},
+		{"TweetDeck", "TweetDeck"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := (Tweet{Source: tt.source}).SourceName(); got != tt.want {
+			t.Errorf("SourceName(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+
+	tweet := unmarshalTweet(t, fixture(t, "tweet_details"))
+	if got := tweet.SourceName(); got != "Twitter Web App" {
+		t.Errorf("SourceName of the fixture = %q", got)
+	}
+}
+
+func TestEngagementRate(t *testing.T) {
+	tweet := Tweet{FavoriteCount: 60, RetweetCount: 20, ReplyCount: 15, QuoteCount: 5, Views: 1000}
+	if got := tweet.TotalEngagements(); got != 100 {
+		t.Errorf("TotalEngagements = %d", got)
+	}
+	if got := tweet.EngagementRate(); got != 0.1 {
+		t.Errorf("EngagementRate = %v, want 0.1", got)
+	}
+
+	tweet.Views = 0
+	if got := tweet.EngagementRate(); got != 100 {
+		t.Errorf("EngagementRate without views = %v, want 100", got)
+	}
+	if got := (Tweet{}).EngagementRate(); got != 0 {
+		t.Errorf("EngagementRate of an empty tweet = %v", got)
+	}
+}
+
+func TestInReplyToUserId(t *testing.T) {
+	tests := []struct {
+		data      string
+		want      string
+		selfReply bool
+	}{
+		{`{"user":{"user_id":"783214"},"in_reply_to_user_id":"783214"}`, "783214", true},
+		{`{"user":{"user_id":"783214"},"in_reply_to_user_id":783214}`, "783214", true},
+		{`{"user":{"user_id":"783214"},"in_reply_to_user_id":"12"}`, "12", false},
+		{`{"user":{"user_id":"783214"},"in_reply_to_user_id":null}`, "", false},
+		{`{"user":{"user_id":"783214"}}`, "", false},
+	}
+	for _, tt := range tests {
+		tweet := unmarshalTweet(t, tt.data)
+
+		got := ""
+		if tweet.InReplyToUserId != nil {
+			got = *tweet.InReplyToUserId
+		}
+		if got != tt.want || tweet.IsSelfReply() != tt.selfReply {
+			t.Errorf("%s: InReplyToUserId = %q, IsSelfReply = %v", tt.data, got, tweet.IsSelfReply())
+		}
+	}
+
+	var tweet Tweet
+	if err := json.Unmarshal([]byte(`{"in_reply_to_user_id":true}`), &tweet); err == nil {
+		t.Error("boolean in_reply_to_user_id accepted")
+	}
+}
+
+func TestPossiblySensitive(t *testing.T) {
+	tests := []struct {
+		data      string
+		present   bool
+		sensitive bool
+	}{
+		{`{"possibly_sensitive":true}`, true, true},
+		{`{"possibly_sensitive":false}`, true, false},
+		{`{}`, false, false},
+	}
+	for _, tt := range tests {
+		tweet := unmarshalTweet(t, tt.data)
+		if (tweet.PossiblySensitive != nil) != tt.present || tweet.IsSensitive() != tt.sensitive {
+			t.Errorf("%s: PossiblySensitive = %v, IsSensitive = %v", tt.data, tweet.PossiblySensitive, tweet.IsSensitive())
+		}
+	}
+}
+
+func TestCardImage(t *testing.T) {
+	tweet := unmarshalTweet(t, fixture(t, "tweet_details"))
+
+	url, width, height, ok := tweet.CardImage()
+	if !ok || url != "https://pbs.twimg.com/card_img/1707913395413270958/a?format=jpg&name=orig" || width != 1200 || height != 628 {
+		t.Errorf("CardImage = %q, %d, %d, %v, want the original size", url, width, height, ok)
+	}
+
+	_, _, _, ok = Tweet{}.CardImage()
+	if ok {
+		t.Error("CardImage of a tweet without a card succeeded")
+	}
+}
+
+func TestListMode(t *testing.T) {
+	var list List
+	err := json.Unmarshal([]byte(`{"list_id":"1","mode":"Private"}`), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Mode != ListModePrivate || list.IsPublic() {
+		t.Errorf("mode = %q, IsPublic = %v", list.Mode, list.IsPublic())
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var _twitterHosts = map[string]bool{
+	"twitter.com":        true,
+	"www.twitter.com":    true,
+	"mobile.twitter.com": true,
+	"x.com":              true,
+	"www.x.com":          true,
+	"mobile.x.com":       true,
+}
+
+// splitTwitterURL returns the non-empty path segments of a twitter.com or
+// x.com URL. A URL without a scheme, such as x.com/jack/status/20 as copied
+// from some apps, is taken to be https.
+func splitTwitterURL(rawURL string) (segments []string, err error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "//") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnrecognizedURL, err)
+	}
+
+	if !_twitterHosts[strings.ToLower(u.Hostname())] {
+		return nil, fmt.Errorf("%w: unknown host %q", ErrUnrecognizedURL, u.Host)
+	}
+
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments, nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTweetURL returns the tweet ID in a tweet URL such as
+// https://x.com/user/status/20 or https://twitter.com/i/web/status/20.
+func ParseTweetURL(rawURL string) (tweetId string, err error) {
+	segments, err := splitTwitterURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "status" || segments[i] == "statuses" {
+			if isNumeric(segments[i+1]) {
+				return segments[i+1], nil
+			}
+			break
+		}
+	}
+
+	return "", fmt.Errorf("%w: no tweet ID in %q", ErrUnrecognizedURL, rawURL)
+}
+
+// ParseListURL returns the list ID in a list URL such as
+// https://x.com/i/lists/12345 or https://twitter.com/i/lists/12345/members.
+// URLs naming a list by its owner and slug carry no ID and aren't
+// recognized.
+func ParseListURL(rawURL string) (listId string, err error) {
+	segments, err := splitTwitterURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "lists" {
+			if isNumeric(segments[i+1]) {
+				return segments[i+1], nil
+			}
+			break
+		}
+	}
+
+	return "", fmt.Errorf("%w: no list ID in %q", ErrUnrecognizedURL, rawURL)
+}
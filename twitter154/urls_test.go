@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseTweetURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://twitter.com/jack/status/20", "20"},
+		{"https://x.com/jack/status/20?s=20&t=abc", "20"},
+		{"https://mobile.twitter.com/jack/status/20/photo/1", "20"},
+		{"http://www.twitter.com/jack/statuses/20", "20"},
+		{"https://twitter.com/i/web/status/20", "20"},
+		{"  https://X.com/jack/status/20/  ", "20"},
+		{"x.com/jack/status/20", "20"},
+		{"www.twitter.com/jack/status/20?s=20", "20"},
+		{"//twitter.com/jack/status/20", "20"},
+	}
+	for _, tt := range tests {
+		got, err := ParseTweetURL(tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTweetURL(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+
+	for _, url := range []string{
+		"",
+		"https://example.com/jack/status/20",
+		"https://twitter.com/jack",
+		"https://twitter.com/jack/status/abc",
+		"https://twitter.com/jack/status",
+		"https://twitter.com/jack/likes/20",
+		"://bad",
+		"example.com/jack/status/20",
+		"jack/status/20",
+	} {
+		if got, err := ParseTweetURL(url); !errors.Is(err, ErrUnrecognizedURL) {
+			t.Errorf("ParseTweetURL(%q) = %q, %v, want ErrUnrecognizedURL", url, got, err)
+		}
+	}
+}
+
+func TestParseListURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://x.com/i/lists/1591033111726391297", "1591033111726391297"},
+		{"https://twitter.com/i/lists/1591033111726391297/members", "1591033111726391297"},
+		{"https://mobile.twitter.com/i/lists/12345?s=20", "12345"},
+		{"x.com/i/lists/12345", "12345"},
+	}
+	for _, tt := range tests {
+		got, err := ParseListURL(tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseListURL(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+
+	for _, url := range []string{
+		"https://twitter.com/previewuser/lists/testing",
+		"https://x.com/i/lists",
+		"https://example.com/i/lists/12345",
+	} {
+		if got, err := ParseListURL(url); !errors.Is(err, ErrUnrecognizedURL) {
+			t.Errorf("ParseListURL(%q) = %q, %v, want ErrUnrecognizedURL", url, got, err)
+		}
+	}
+}
+
+func TestGetTweetByURL(t *testing.T) {
+	c, m := newFixtureClient(t)
+
+	_, err := c.GetTweetByURL(context.Background(), "https://example.com/status/1")
+	if !errors.Is(err, ErrUnrecognizedURL) {
+		t.Errorf("err = %v, want ErrUnrecognizedURL", err)
+	}
+	if n := len(m.sent()); n != 0 {
+		t.Errorf("sent %d requests for an unrecognized URL", n)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import "fmt"
+
+// DiffFollowers compares two snapshots of a follower list by user ID.
+// gained holds users in new but not in old, in the order they appear in new;
+// lost holds users in old but not in new, in the order they appear in old.
+// A user listed more than once appears at most once in either result.
+func DiffFollowers(old, new []User) (gained, lost []User) {
+	return subtractUsers(new, old), subtractUsers(old, new)
+}
+
+// subtractUsers returns the users of a whose IDs aren't in b, deduplicated
+// and in order of appearance.
+func subtractUsers(a, b []User) []User {
+	exclude := make(map[string]bool, len(a)+len(b))
+	for _, u := range b {
+		exclude[u.UserId] = true
+	}
+
+	diff := []User{}
+	for _, u := range a {
+		if exclude[u.UserId] {
+			continue
+		}
+		exclude[u.UserId] = true
+		diff = append(diff, u)
+	}
+	return diff
+}
+
+// IntersectUsers returns the users of a whose IDs are also in b,
+// deduplicated and in the order they appear in a.
+func IntersectUsers(a, b []User) []User {
+	include := make(map[string]bool, len(b))
+	for _, u := range b {
+		include[u.UserId] = true
+	}
+
+	both := []User{}
+	for _, u := range a {
+		if !include[u.UserId] {
+			continue
+		}
+		include[u.UserId] = false
+		both = append(both, u)
+	}
+	return both
+}
+
+// Mutuals returns the accounts followed by both users, in the order they
+// appear in the first user's following. It fetches both following lists in
+// full, one after the other, so the client's rate limit and options such as
+// WithGlobalMaxResults apply as they do to GetUserFollowing.
+func (c *Client) Mutuals(userIdA, userIdB string) (mutuals []User, err error) {
+	a, err := c.GetUserFollowing(userIdA)
+	if err != nil {
+		return nil, fmt.Errorf("get following of %s: %w", userIdA, err)
+	}
+
+	b, err := c.GetUserFollowing(userIdB)
+	if err != nil {
+		return nil, fmt.Errorf("get following of %s: %w", userIdB, err)
+	}
+
+	return IntersectUsers(a, b), nil
+}
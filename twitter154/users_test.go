@@ -0,0 +1,41 @@
+package api
+
+import "testing"
+
+func users(ids ...string) []User {
+	users := make([]User, len(ids))
+	for i, id := range ids {
+		users[i] = User{UserId: id}
+	}
+	return users
+}
+
+func TestDiffFollowers(t *testing.T) {
+	old := users("1", "2", "3", "3")
+	new := users("4", "2", "4", "5", "1")
+
+	gained, lost := DiffFollowers(old, new)
+	if ids := userIds(gained); !equalStrings(ids, []string{"4", "5"}) {
+		t.Errorf("gained = %v", ids)
+	}
+	if ids := userIds(lost); !equalStrings(ids, []string{"3"}) {
+		t.Errorf("lost = %v", ids)
+	}
+
+	gained, lost = DiffFollowers(nil, nil)
+	if gained == nil || lost == nil || len(gained)+len(lost) != 0 {
+		t.Errorf("DiffFollowers(nil, nil) = %#v, %#v, want empty slices", gained, lost)
+	}
+}
+
+func TestIntersectUsers(t *testing.T) {
+	both := IntersectUsers(users("1", "2", "3", "2"), users("3", "2", "9"))
+	if ids := userIds(both); !equalStrings(ids, []string{"2", "3"}) {
+		t.Errorf("IntersectUsers = %v", ids)
+	}
+
+	both = IntersectUsers(users("1"), nil)
+	if both == nil || len(both) != 0 {
+		t.Errorf("IntersectUsers with no overlap = %#v, want an empty slice", both)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import "fmt"
+
+// validate checks that a result has the fields that identify it, if the
+// WithValidateResponses option is set. Results of other types are not
+// checked.
+func (c *Client) validate(v any) error {
+	if !c.options.validateResponses {
+		return nil
+	}
+
+	switch v := v.(type) {
+	case Tweet:
+		if v.TweetId == "" {
+			return fmt.Errorf("tweet without tweet_id: %w", ErrInvalidResponse)
+		}
+		if v.User.UserId == "" {
+			return fmt.Errorf("tweet %s without user.user_id: %w", v.TweetId, ErrInvalidResponse)
+		}
+	case User:
+		if v.UserId == "" {
+			return fmt.Errorf("user without user_id: %w", ErrInvalidResponse)
+		}
+	case List:
+		if v.ListId == "" {
+			return fmt.Errorf("list without list_id: %w", ErrInvalidResponse)
+		}
+	}
+
+	return nil
+}
+
+// validatePage validates each result of a page.
+func validatePage[T any](c *Client, results []T) error {
+	if !c.options.validateResponses {
+		return nil
+	}
+
+	for i, r := range results {
+		err := c.validate(r)
+		if err != nil {
+			return fmt.Errorf("result %d: %w", i, err)
+		}
+	}
+	return nil
+}
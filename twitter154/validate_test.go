@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateResponses(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: `{"username":"X"}`}}},
+		{path: "/tweet/details", responses: []response{{body: `{"tweet_id":"1","user":{}}`}}},
+		{path: "/lists/details", responses: []response{{body: `{"name":"testing"}`}}},
+		{path: "/user/followers", responses: []response{{body: `{"results":[{"user_id":"1"},{"user_id":""}]}`}}},
+	}, WithValidateResponses())
+
+	_, err := c.GetUser("783214")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("GetUser: err = %v, want ErrInvalidResponse", err)
+	}
+	_, err = c.GetTweetDetails("1")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("GetTweetDetails: err = %v, want ErrInvalidResponse", err)
+	}
+	_, err = c.GetListDetails("1")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("GetListDetails: err = %v, want ErrInvalidResponse", err)
+	}
+	_, err = c.GetUserFollowers("783214")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("GetUserFollowers: err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestValidateResponsesDisabled(t *testing.T) {
+	c, _ := newTestClient(t, []route{
+		{path: "/user/details", responses: []response{{body: `{"username":"X"}`}}},
+	})
+
+	user, err := c.GetUser("783214")
+	if err != nil {
+		t.Errorf("GetUser without validation: %v", err)
+	}
+	if user.Username != "X" {
+		t.Errorf("user = %+v", user)
+	}
+}